@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+
+	"common/config"
+
+	"basic_rag/rag"
+)
+
+// runEval 离线评测检索效果: go run . eval [-rag.store es|memory] [-rag.eval_queries path] [-embedder hash|llm] [-k 10] [-dims 256] [-rag.rrf_k 60] [-rag.bm25_weight 1] [-rag.vector_weight 1]
+// 默认使用确定性的哈希 embedder，评测过程不调用任何在线模型；memory 后端无需 Elasticsearch
+// -embedder llm 使用配置的向量模型，向量经 embedding_cache 缓存，重复评测几乎不再调用模型
+func runEval(ctx context.Context, args []string, out io.Writer) error {
+	opts := &rag.EvalOptions{}
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.IntVar(&opts.K, "k", 10, "评测截断位置 k")
+	fs.StringVar(&opts.Embedder, "embedder", "hash", "向量化方式：hash 本地哈希，llm 配置的向量模型")
+	fs.IntVar(&opts.Dims, "dims", 256, "哈希 embedder 向量维度")
+	if err := config.Load(fs, args, cfg); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	return rag.Evaluate(ctx, cfg, opts, out)
+}
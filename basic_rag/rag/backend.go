@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// vectorBackend 向量存储后端：负责索引文本块并提供混合检索器
+type vectorBackend interface {
+	// Index 增量索引文本块，返回本次写入的文本块 ID
+	Index(ctx context.Context, docs []*schema.Document) ([]string, error)
+	// Retriever 创建 BM25 + 向量的融合检索器，conf 中与具体后端相关的字段由后端填充
+	Retriever(ctx context.Context, conf *fusionRetrieverConfig) (retriever.Retriever, error)
+}
+
+// newVectorBackend 按 rag.store 创建向量存储后端：es、memory
+func newVectorBackend(c *Config, embedder embedding.Embedder) (vectorBackend, error) {
+	switch c.RAG.Store {
+	case "es":
+		client, err := createESClient(&c.ES)
+		if err != nil {
+			return nil, err
+		}
+		return &esBackend{client: client, embedder: embedder, es: &c.ES, dims: c.LLM.EmbeddingDims, manifestPath: c.RAG.ManifestPath}, nil
+	case "memory":
+		store, err := newMemoryStore(embedder, c.embeddingModel(), c.RAG.MemoryPath)
+		if err != nil {
+			return nil, err
+		}
+		return &memoryBackend{store: store}, nil
+	default:
+		return nil, fmt.Errorf("未知的向量存储后端: %s", c.RAG.Store)
+	}
+}
+
+// esBackend Elasticsearch 后端，通过别名切换实现无停机重建索引
+type esBackend struct {
+	client       *elasticsearch.Client
+	embedder     embedding.Embedder
+	es           *ESConfig
+	dims         int // 配置的向量维度，0 表示创建索引时探测
+	manifestPath string
+}
+
+func (b *esBackend) Index(ctx context.Context, docs []*schema.Document) ([]string, error) {
+	target, err := ensureIndex(ctx, b.client, b.embedder, b.es, b.es.Index, b.dims)
+	if err != nil {
+		return nil, fmt.Errorf("创建索引失败: %w", err)
+	}
+	ids, err := indexDocuments(ctx, b.client, b.embedder, target, docs, b.manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := switchAlias(ctx, b.client, b.es.Index, target); err != nil {
+		return nil, fmt.Errorf("切换索引别名失败: %w", err)
+	}
+	return ids, nil
+}
+
+func (b *esBackend) Retriever(ctx context.Context, conf *fusionRetrieverConfig) (retriever.Retriever, error) {
+	conf.Client = b.client
+	conf.Index = b.es.Index
+	conf.Embedding = b.embedder
+	return newFusionRetriever(ctx, conf)
+}
+
+// memoryBackend 进程内后端，适合本地开发与离线评测
+type memoryBackend struct {
+	store *memoryStore
+}
+
+func (b *memoryBackend) Index(ctx context.Context, docs []*schema.Document) ([]string, error) {
+	return b.store.Sync(ctx, docs)
+}
+
+func (b *memoryBackend) Retriever(_ context.Context, conf *fusionRetrieverConfig) (retriever.Retriever, error) {
+	conf.setDefaults()
+	return fuseRetrievers(
+		newMemoryRetriever(b.store, memorySearchBM25, conf.CandidateK),
+		newMemoryRetriever(b.store, memorySearchKNN, conf.CandidateK),
+		conf,
+	), nil
+}
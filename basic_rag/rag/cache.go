@@ -0,0 +1,41 @@
+package rag
+
+import (
+	"context"
+	"log"
+
+	"github.com/cloudwego/eino/components/embedding"
+
+	"common/embedcache"
+	"common/provider"
+)
+
+// newEmbedder 创建向量模型，配置了 embedding_cache.path 时套上本地缓存，
+// 返回的 closer 输出缓存命中统计并关闭缓存文件
+func newEmbedder(ctx context.Context, c *Config) (embedding.Embedder, func(), error) {
+	embedder, err := provider.NewEmbedder(ctx, &c.LLM)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Cache.Path == "" {
+		return embedder, func() {}, nil
+	}
+
+	cache, err := embedcache.New(embedder, &embedcache.Config{
+		Path:       c.Cache.Path,
+		Model:      c.embeddingModel(),
+		Dims:       c.LLM.EmbeddingDims,
+		MaxEntries: c.Cache.MaxEntries,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	closer := func() {
+		s := cache.Stats()
+		log.Printf("向量缓存: 命中 %d，未命中 %d，命中率 %.1f%%，共缓存 %d 条", s.Hits, s.Misses, s.HitRate()*100, s.Entries)
+		if err := cache.Close(); err != nil {
+			log.Printf("关闭向量缓存失败: %v", err)
+		}
+	}
+	return cache, closer, nil
+}
@@ -0,0 +1,160 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 条文元数据字段
+const (
+	metaSource         = "source"          // 来源文档
+	metaParagraphIndex = "paragraph_index" // 条文在文档中的顺序
+	metaClauseID       = "clause_id"       // 条文编号，如 2.3.2.1
+	metaParentClause   = "parent_clause"   // 上级条文编号，如 2.3.2
+	metaSyndromeName   = "syndrome_name"   // 证候名称
+	metaAliases        = "aliases"         // 证候别名
+	metaDepth          = "depth"           // 条文层级深度
+)
+
+// clauseIDPattern 匹配独占一行的条文编号
+var clauseIDPattern = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// clause 标准中的一个条文
+type clause struct {
+	ID          string   // 条文编号
+	Name        string   // 证候名称
+	Aliases     []string // 别名
+	Description string   // 条文描述
+}
+
+// parentClause 返回上级条文编号，顶层条文返回空
+func (c *clause) parentClause() string {
+	idx := strings.LastIndex(c.ID, ".")
+	if idx < 0 {
+		return ""
+	}
+	return c.ID[:idx]
+}
+
+// depth 返回条文层级，2.3.1 为 3
+func (c *clause) depth() int {
+	return strings.Count(c.ID, ".") + 1
+}
+
+// content 返回用于索引的条文文本
+func (c *clause) content() string {
+	var b strings.Builder
+	b.WriteString(c.ID)
+	if c.Name != "" {
+		b.WriteString(" ")
+		b.WriteString(c.Name)
+	}
+	if len(c.Aliases) > 0 {
+		b.WriteString("（又称：")
+		b.WriteString(strings.Join(c.Aliases, "、"))
+		b.WriteString("）")
+	}
+	if c.Description != "" {
+		b.WriteString("\n")
+		b.WriteString(c.Description)
+	}
+	return b.String()
+}
+
+// parseClauses 按条文编号解析标准文本
+// 条文格式：编号行、证候名称行、若干别名行、描述正文。首个条文之前的内容作为前言返回
+func parseClauses(content string) (preamble string, clauses []*clause) {
+	var (
+		pre    []string
+		cur    *clause
+		inBody bool
+		body   []string
+		flush  = func() {
+			if cur == nil {
+				return
+			}
+			cur.Description = strings.Join(body, "\n")
+			clauses = append(clauses, cur)
+		}
+	)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if clauseIDPattern.MatchString(line) {
+			flush()
+			cur, inBody, body = &clause{ID: line}, false, nil
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if cur == nil {
+			pre = append(pre, line)
+			continue
+		}
+
+		switch {
+		case cur.Name == "":
+			// 名称行末尾的 "-" 表示后面跟有别名
+			cur.Name = strings.TrimSpace(strings.TrimRight(line, "- "))
+		case !inBody && !isDescriptionLine(line):
+			cur.Aliases = append(cur.Aliases, line)
+		default:
+			inBody = true
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	return strings.Join(pre, "\n"), clauses
+}
+
+// isDescriptionLine 判断是否为描述正文，别名行不含句号
+func isDescriptionLine(line string) bool {
+	return strings.Contains(line, "。")
+}
+
+// chunkDocuments 将文档按条文分块，每个条文一个文本块
+func chunkDocuments(docs []*schema.Document) []*schema.Document {
+	var chunkedDocs []*schema.Document
+
+	for _, doc := range docs {
+		preamble, clauses := parseClauses(doc.Content)
+
+		idx := 0
+		if preamble != "" {
+			chunkedDocs = append(chunkedDocs, &schema.Document{
+				ID:      fmt.Sprintf("%s_preamble", doc.ID),
+				Content: preamble,
+				MetaData: map[string]any{
+					metaSource:         doc.ID,
+					metaParagraphIndex: idx,
+					metaDepth:          0,
+				},
+			})
+			idx++
+		}
+
+		for _, c := range clauses {
+			chunkedDocs = append(chunkedDocs, &schema.Document{
+				ID:      fmt.Sprintf("%s_clause_%s", doc.ID, c.ID),
+				Content: c.content(),
+				MetaData: map[string]any{
+					metaSource:         doc.ID,
+					metaParagraphIndex: idx,
+					metaClauseID:       c.ID,
+					metaParentClause:   c.parentClause(),
+					metaSyndromeName:   c.Name,
+					metaAliases:        c.Aliases,
+					metaDepth:          c.depth(),
+				},
+			})
+			idx++
+		}
+	}
+
+	return chunkedDocs
+}
@@ -0,0 +1,91 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestParseClauses(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantPreamble string
+		want         []clause
+	}{
+		{
+			name:    "名称、别名与描述",
+			content: "2.3.1\n风寒束表证-\n风寒表证\n寒邪束表证\n风寒之邪外袭肌表。以恶寒重、发热轻为主症。\n",
+			want: []clause{{
+				ID:          "2.3.1",
+				Name:        "风寒束表证",
+				Aliases:     []string{"风寒表证", "寒邪束表证"},
+				Description: "风寒之邪外袭肌表。以恶寒重、发热轻为主症。",
+			}},
+		},
+		{
+			name:         "前言与多个条文",
+			content:      "中医临床诊疗术语\n证候部分\n\n2\n证候\n\n2.1\n表证\n病位在表的证候。",
+			wantPreamble: "中医临床诊疗术语\n证候部分",
+			want: []clause{
+				{ID: "2", Name: "证候"},
+				{ID: "2.1", Name: "表证", Description: "病位在表的证候。"},
+			},
+		},
+		{
+			name:         "没有条文",
+			content:      "只有前言。",
+			wantPreamble: "只有前言。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preamble, clauses := parseClauses(tt.content)
+			if preamble != tt.wantPreamble {
+				t.Errorf("preamble = %q, want %q", preamble, tt.wantPreamble)
+			}
+			var got []clause
+			for _, c := range clauses {
+				got = append(got, *c)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clauses = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkDocuments(t *testing.T) {
+	docs := chunkDocuments([]*schema.Document{{
+		ID:      "tcm.txt",
+		Content: "前言\n2.3\n表寒证\n2.3.1\n风寒束表证-\n风寒表证\n风寒之邪外袭肌表。",
+	}})
+
+	tests := []struct {
+		id       string
+		clauseID any
+		parent   any
+		depth    any
+		content  string
+	}{
+		{id: "tcm.txt_preamble", depth: 0, content: "前言"},
+		{id: "tcm.txt_clause_2.3", clauseID: "2.3", parent: "2", depth: 2, content: "2.3 表寒证"},
+		{id: "tcm.txt_clause_2.3.1", clauseID: "2.3.1", parent: "2.3", depth: 3, content: "2.3.1 风寒束表证（又称：风寒表证）\n风寒之邪外袭肌表。"},
+	}
+	if len(docs) != len(tests) {
+		t.Fatalf("got %d chunks, want %d", len(docs), len(tests))
+	}
+	for i, tt := range tests {
+		doc := docs[i]
+		if doc.ID != tt.id || doc.Content != tt.content {
+			t.Errorf("chunk %d = (%q, %q), want (%q, %q)", i, doc.ID, doc.Content, tt.id, tt.content)
+		}
+		if doc.MetaData[metaClauseID] != tt.clauseID || doc.MetaData[metaParentClause] != tt.parent || doc.MetaData[metaDepth] != tt.depth {
+			t.Errorf("chunk %d metadata = %v", i, doc.MetaData)
+		}
+		if doc.MetaData[metaParagraphIndex] != i || doc.MetaData[metaSource] != "tcm.txt" {
+			t.Errorf("chunk %d source metadata = %v", i, doc.MetaData)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package rag
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// citationPattern 匹配回答中的方括号引用，如 [2.3.1] 或 [2.3.1, 2.5.2]
+var citationPattern = regexp.MustCompile(`\[([^\[\]\n]{1,64})\]`)
+
+// citationSeparators 同一对方括号内多个引用的分隔符
+var citationSeparators = regexp.MustCompile(`[,，、;；\s]+`)
+
+// citation 回答中的一条引用
+type citation struct {
+	Label string           // 引用标签，即条文编号
+	Doc   *schema.Document // 对应的检索文档，引用无效时为空
+}
+
+// citationLabel 返回文档在上下文中的引用标签，优先使用条文编号
+func citationLabel(doc *schema.Document) string {
+	if id, ok := doc.MetaData[metaClauseID].(string); ok && id != "" {
+		return id
+	}
+	return doc.ID
+}
+
+// extractCitations 按出现顺序解析回答中的引用并去重，校验是否指向检索到的文档
+func extractCitations(answer string, docs []*schema.Document) []citation {
+	byLabel := make(map[string]*schema.Document, len(docs))
+	for _, doc := range docs {
+		byLabel[citationLabel(doc)] = doc
+	}
+
+	var (
+		citations []citation
+		seen      = make(map[string]bool)
+	)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, label := range citationSeparators.Split(m[1], -1) {
+			label = strings.TrimSpace(label)
+			if label == "" || seen[label] {
+				continue
+			}
+			seen[label] = true
+			citations = append(citations, citation{Label: label, Doc: byLabel[label]})
+		}
+	}
+	return citations
+}
+
+// printReferences 输出参考条文列表，无效引用单独标出
+func printReferences(w io.Writer, citations []citation) {
+	if len(citations) == 0 {
+		fmt.Fprintln(w, "\n\n参考条文: 回答未引用任何条文")
+		return
+	}
+
+	fmt.Fprintln(w, "\n\n参考条文:")
+	for _, c := range citations {
+		if c.Doc == nil {
+			fmt.Fprintf(w, "  [%s] 无效引用，未在检索结果中找到\n", c.Label)
+			continue
+		}
+		name, _ := c.Doc.MetaData[metaSyndromeName].(string)
+		fmt.Fprintf(w, "  [%s] %s %s\n", c.Label, name, snippet(c.Doc.Content, 60))
+	}
+}
+
+// snippet 截取文档正文前 n 个字符，跳过首行的条文编号与名称
+func snippet(content string, n int) string {
+	if idx := strings.Index(content, "\n"); idx >= 0 {
+		content = content[idx+1:]
+	}
+	content = strings.ReplaceAll(content, "\n", " ")
+	runes := []rune(content)
+	if len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return content
+}
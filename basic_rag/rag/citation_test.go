@@ -0,0 +1,44 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestExtractCitations(t *testing.T) {
+	docs := []*schema.Document{
+		{ID: "tcm.txt_clause_2.3.1", MetaData: map[string]any{metaClauseID: "2.3.1"}},
+		{ID: "tcm.txt_clause_2.5.2", MetaData: map[string]any{metaClauseID: "2.5.2"}},
+		{ID: "tcm.txt_preamble", MetaData: map[string]any{}},
+	}
+
+	tests := []struct {
+		name      string
+		answer    string
+		wantLabel []string
+		wantValid []bool
+	}{
+		{name: "没有引用", answer: "风寒感冒宜辛温解表。"},
+		{name: "单个引用", answer: "恶寒重发热轻 [2.3.1]。", wantLabel: []string{"2.3.1"}, wantValid: []bool{true}},
+		{name: "同一方括号多个引用", answer: "见 [2.3.1，2.5.2]", wantLabel: []string{"2.3.1", "2.5.2"}, wantValid: []bool{true, true}},
+		{name: "去重并保持顺序", answer: "[2.5.2] 与 [2.3.1]，又见 [2.5.2]", wantLabel: []string{"2.5.2", "2.3.1"}, wantValid: []bool{true, true}},
+		{name: "无效引用", answer: "[9.9] [tcm.txt_preamble]", wantLabel: []string{"9.9", "tcm.txt_preamble"}, wantValid: []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				labels []string
+				valid  []bool
+			)
+			for _, c := range extractCitations(tt.answer, docs) {
+				labels = append(labels, c.Label)
+				valid = append(valid, c.Doc != nil)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabel) || !reflect.DeepEqual(valid, tt.wantValid) {
+				t.Errorf("extractCitations() = %v %v, want %v %v", labels, valid, tt.wantLabel, tt.wantValid)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+
+	"common/config"
+)
+
+// Config RAG 流程的全部配置，字段与配置文件中的同名块对应
+type Config struct {
+	LLM       config.LLM      `yaml:"llm"`
+	ES        ESConfig        `yaml:"es"`
+	RAG       RAGConfig       `yaml:"rag"`
+	Rerank    RerankConfig    `yaml:"rerank"`
+	Grounding GroundingConfig `yaml:"grounding"`
+	Cache     CacheConfig     `yaml:"embedding_cache"`
+}
+
+// ESConfig Elasticsearch 连接与索引结构
+type ESConfig struct {
+	Address  string `yaml:"address" env:"ES_ADDRESS" usage:"ES 地址"`
+	Username string `yaml:"username" env:"ES_USERNAME" usage:"ES 用户名"`
+	Password string `yaml:"password" env:"ES_PASSWORD" usage:"ES 密码"`
+	Index    string `yaml:"index" usage:"索引别名，实际索引名为 别名_v版本号"`
+
+	// MappingVersion 索引结构版本，修改 mapping 或更换 embedding 模型时递增，
+	// 会创建新的版本索引并在全量索引完成后把别名切换过去
+	MappingVersion int `yaml:"mapping_version" usage:"索引结构版本"`
+	// Analyzer BM25 字段使用的分词器
+	// cjk 为 ES 内置的中日韩二元分词；安装插件后可改为 ik_max_word 或 smartcn
+	Analyzer string `yaml:"analyzer" usage:"BM25 字段分词器"`
+	// SearchAnalyzer 查询时使用的分词器，为空时与 Analyzer 一致
+	SearchAnalyzer string `yaml:"search_analyzer" usage:"查询分词器"`
+	Similarity     string `yaml:"similarity" usage:"向量相似度"`
+}
+
+// RAGConfig 文档、存储与检索
+type RAGConfig struct {
+	DataPath     string `yaml:"data_path" usage:"知识库文档路径"`
+	Store        string `yaml:"store" env:"VECTOR_STORE" usage:"向量存储后端：es 或 memory（进程内，无需 Elasticsearch）"`
+	MemoryPath   string `yaml:"memory_path" usage:"memory 后端的持久化文件，为空时只保存在内存中"`
+	ManifestPath string `yaml:"manifest_path" usage:"增量索引清单"`
+
+	RetrieveTopK       int    `yaml:"retrieve_top_k" usage:"混合检索召回数量"`
+	ContextTopK        int    `yaml:"context_top_k" usage:"重排后进入提示词的文档数量"`
+	HistoryTokenBudget int    `yaml:"history_token_budget" usage:"多轮对话历史的 token 预算"`
+	EvalQueries        string `yaml:"eval_queries" usage:"评测集：每行一个 {\"query\": ..., \"expected\": [条文编号]}"`
+
+	Fusion       string  `yaml:"fusion" usage:"BM25 与向量结果的融合方式：rrf 倒数排名融合，weighted 归一化加权"`
+	RRFK         int     `yaml:"rrf_k" usage:"RRF 常数 k，越大排名靠后的文档影响越大"`
+	BM25Weight   float64 `yaml:"bm25_weight" usage:"融合时 BM25 权重"`
+	VectorWeight float64 `yaml:"vector_weight" usage:"融合时向量检索权重"`
+}
+
+// fusionOptions 配置的融合方式与参数
+func (c *RAGConfig) fusionOptions() fusionOptions {
+	return fusionOptions{
+		Method:       fusionMethod(c.Fusion),
+		RRFK:         c.RRFK,
+		BM25Weight:   c.BM25Weight,
+		VectorWeight: c.VectorWeight,
+	}
+}
+
+// RerankConfig 重排器
+type RerankConfig struct {
+	Type   string `yaml:"type" usage:"重排器：lexical 本地字面匹配、llm 大模型打分、http cross-encoder 服务"`
+	URL    string `yaml:"url" usage:"cross-encoder 重排服务地址"`
+	APIKey string `yaml:"api_key" env:"RERANK_API_KEY" usage:"cross-encoder 重排服务密钥"`
+	Model  string `yaml:"model" usage:"cross-encoder 重排模型"`
+}
+
+// GroundingConfig 回答依据校验
+type GroundingConfig struct {
+	Mode             string  `yaml:"mode" usage:"回答依据校验方式：空为关闭，lexical 字面重合度，llm 大模型判定"`
+	Threshold        float64 `yaml:"threshold" usage:"论断支持率低于该值视为依据不足"`
+	Regenerate       bool    `yaml:"regenerate" usage:"依据不足时是否重新生成一次"`
+	LexicalThreshold float64 `yaml:"lexical_threshold" usage:"字面校验时论断字符二元组被单个文档覆盖的最低比例"`
+}
+
+// CacheConfig 本地向量缓存，重复索引与查询时不再调用向量模型
+type CacheConfig struct {
+	Path       string `yaml:"path" usage:"向量缓存文件，为空时不缓存"`
+	MaxEntries int    `yaml:"max_entries" usage:"最多缓存的向量数，超出时淘汰最久未使用的，0 不限"`
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		LLM: config.DefaultLLM(),
+		ES: ESConfig{
+			Address:        "http://localhost:9200",
+			Index:          "eino_rag_demo",
+			MappingVersion: 1,
+			Analyzer:       "cjk",
+			Similarity:     "cosine",
+		},
+		RAG: RAGConfig{
+			DataPath:           "../data/tcm.txt",
+			Store:              "es",
+			MemoryPath:         "./memory_store.json",
+			ManifestPath:       "./index_manifest.json",
+			RetrieveTopK:       20,
+			ContextTopK:        3,
+			HistoryTokenBudget: 2000,
+			EvalQueries:        "../data/tcm_eval.jsonl",
+			Fusion:             string(fusionRRF),
+			RRFK:               60,
+			BM25Weight:         1,
+			VectorWeight:       1,
+		},
+		Rerank: RerankConfig{Type: "lexical"},
+		Grounding: GroundingConfig{
+			Threshold:        0.6,
+			Regenerate:       true,
+			LexicalThreshold: 0.5,
+		},
+		Cache: CacheConfig{
+			Path:       "./embedding_cache.db",
+			MaxEntries: 20000,
+		},
+	}
+}
+
+// embeddingModel 向量模型标识，向量缓存与内存存储据此判断已有向量是否可复用
+func (c *Config) embeddingModel() string {
+	return c.LLM.Provider + "/" + c.LLM.EmbeddingModel
+}
+
+// Validate 校验全部配置
+func (c *Config) Validate() error {
+	llmErr := c.LLM.Validate()
+	if llmErr == nil && c.LLM.EmbeddingModel == "" {
+		llmErr = fmt.Errorf("未配置 llm.embedding_model：%s 没有默认向量模型", c.LLM.Provider)
+	}
+	return errors.Join(llmErr, c.validateRetrieval())
+}
+
+// validateRetrieval 校验与大模型无关的配置，离线评测只需要这一部分
+func (c *Config) validateRetrieval() error {
+	var errs []error
+	if c.RAG.DataPath == "" {
+		errs = append(errs, errors.New("未配置 rag.data_path"))
+	}
+	switch c.RAG.Store {
+	case "es":
+		if c.ES.Address == "" || c.ES.Index == "" {
+			errs = append(errs, errors.New("es 后端需要配置 es.address 与 es.index"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("rag.store 只能是 es 或 memory，当前为 %q", c.RAG.Store))
+	}
+	if c.RAG.RetrieveTopK <= 0 || c.RAG.ContextTopK <= 0 {
+		errs = append(errs, errors.New("rag.retrieve_top_k 与 rag.context_top_k 必须大于 0"))
+	}
+	if c.RAG.ContextTopK > c.RAG.RetrieveTopK {
+		errs = append(errs, fmt.Errorf("rag.context_top_k (%d) 不能大于 rag.retrieve_top_k (%d)", c.RAG.ContextTopK, c.RAG.RetrieveTopK))
+	}
+	switch fusionMethod(c.RAG.Fusion) {
+	case fusionRRF, fusionWeighted:
+	default:
+		errs = append(errs, fmt.Errorf("rag.fusion 只能是 rrf 或 weighted，当前为 %q", c.RAG.Fusion))
+	}
+	if c.RAG.RRFK <= 0 {
+		errs = append(errs, errors.New("rag.rrf_k 必须大于 0"))
+	}
+	if c.RAG.BM25Weight < 0 || c.RAG.VectorWeight < 0 || c.RAG.BM25Weight+c.RAG.VectorWeight == 0 {
+		errs = append(errs, errors.New("rag.bm25_weight 与 rag.vector_weight 不能小于 0，且不能同时为 0"))
+	}
+	switch c.Rerank.Type {
+	case "lexical", "llm":
+	case "http":
+		if c.Rerank.URL == "" {
+			errs = append(errs, errors.New("http 重排器需要配置 rerank.url"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("未知的重排器 rerank.type=%q", c.Rerank.Type))
+	}
+	switch c.Grounding.Mode {
+	case "", "lexical", "llm":
+	default:
+		errs = append(errs, fmt.Errorf("未知的依据校验方式 grounding.mode=%q", c.Grounding.Mode))
+	}
+	if c.Cache.MaxEntries < 0 {
+		errs = append(errs, errors.New("embedding_cache.max_entries 不能小于 0"))
+	}
+	return errors.Join(errs...)
+}
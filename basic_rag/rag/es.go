@@ -0,0 +1,176 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	es8indexer "github.com/cloudwego/eino-ext/components/indexer/es8"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types" // 用于 Hit 类型
+)
+
+var (
+	fieldContent       = "content"        // 内容字段
+	fieldContentVector = "content_vector" // 向量字段
+)
+
+// createESClient 按配置创建 ES 客户端并测试连接
+func createESClient(c *ESConfig) (*elasticsearch.Client, error) {
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{c.Address},
+		Username:  c.Username,
+		Password:  c.Password,
+		//Logger:    &elastictransport.ColorLogger{Output: os.Stdout, EnableRequestBody: true, EnableResponseBody: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建 ES 客户端失败: %w", err)
+	}
+
+	// 测试连接
+	res, err := client.Info()
+	if err != nil {
+		return nil, fmt.Errorf("连接 ES 失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("ES 返回错误: %s", res.String())
+	}
+
+	return client, nil
+}
+
+// indexDocuments 增量索引文档到 ES，只对新增或内容变化的文本块做向量化，并删除已失效的文本块
+// 已索引文本块的内容哈希记录在 manifestPath 中
+func indexDocuments(ctx context.Context, client *elasticsearch.Client, embedder embedding.Embedder, index string, docs []*schema.Document, manifestPath string) ([]string, error) {
+	manifest, err := loadManifest(manifestPath, index)
+	if err != nil {
+		return nil, err
+	}
+
+	// 索引被清空或重建时清单已失效，全部重新索引
+	count, err := indexDocCount(client, index)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		manifest.Chunks = map[string]string{}
+	}
+
+	changed, stale, hashes := manifest.diff(docs)
+	log.Printf("  - 新增或变更 %d 个，未变化 %d 个，已失效 %d 个", len(changed), len(docs)-len(changed), len(stale))
+
+	if err := deleteDocuments(ctx, client, index, stale); err != nil {
+		return nil, err
+	}
+	for _, id := range stale {
+		delete(manifest.Chunks, id)
+	}
+
+	ids, err := storeDocuments(ctx, client, embedder, index, changed)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range changed {
+		manifest.Chunks[doc.ID] = hashes[doc.ID]
+	}
+
+	if err := manifest.save(manifestPath); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// storeDocuments 向量化并写入文本块
+func storeDocuments(ctx context.Context, client *elasticsearch.Client, embedder embedding.Embedder, index string, docs []*schema.Document) ([]string, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	indexer, err := es8indexer.NewIndexer(ctx, &es8indexer.IndexerConfig{
+		Client:    client,
+		Index:     index,
+		BatchSize: 10,
+		DocumentToFields: func(ctx context.Context, doc *schema.Document) (map[string]es8indexer.FieldValue, error) {
+			fields := map[string]es8indexer.FieldValue{
+				fieldContent: {
+					Value:    doc.Content,
+					EmbedKey: fieldContentVector,
+				},
+				"id":               {Value: doc.ID},
+				metaSource:         {Value: doc.MetaData[metaSource]},
+				metaParagraphIndex: {Value: doc.MetaData[metaParagraphIndex]},
+				metaClauseID:       {Value: doc.MetaData[metaClauseID]},
+				metaParentClause:   {Value: doc.MetaData[metaParentClause]},
+				metaSyndromeName:   {Value: doc.MetaData[metaSyndromeName]},
+				metaDepth:          {Value: doc.MetaData[metaDepth]},
+			}
+
+			return fields, nil
+		},
+		Embedding: embedder,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建索引器失败: %w", err)
+	}
+
+	ids, err := indexer.Store(ctx, docs)
+	if err != nil {
+		return nil, fmt.Errorf("存储文档失败: %w", err)
+	}
+
+	return ids, nil
+}
+
+// parseHit 自定义结果解析器
+func parseHit(ctx context.Context, hit types.Hit) (doc *schema.Document, err error) {
+	if hit.Source_ == nil {
+		return nil, fmt.Errorf("hit source is nil")
+	}
+
+	// 反序列化 JSON 源数据
+	var source map[string]interface{}
+	if err := json.Unmarshal(hit.Source_, &source); err != nil {
+		return nil, fmt.Errorf("unmarshal source failed: %w", err)
+	}
+
+	// 解析文档内容
+	content, ok := source[fieldContent].(string)
+	if !ok {
+		return nil, fmt.Errorf("content field not found or not a string")
+	}
+
+	// 获取文档 ID
+	docID := ""
+	if hit.Id_ != nil {
+		docID = *hit.Id_
+	}
+
+	// 创建文档
+	doc = &schema.Document{
+		ID:       docID,
+		Content:  content,
+		MetaData: map[string]any{},
+	}
+
+	if hit.Score_ != nil {
+		doc.WithScore(float64(*hit.Score_))
+	}
+	if source[metaParagraphIndex] != nil {
+		doc.MetaData[metaParagraphIndex] = source[metaParagraphIndex].(float64)
+	}
+	for _, key := range []string{metaSource, metaClauseID, metaParentClause, metaSyndromeName} {
+		if v, ok := source[key].(string); ok {
+			doc.MetaData[key] = v
+		}
+	}
+	if v, ok := source[metaDepth].(float64); ok {
+		doc.MetaData[metaDepth] = int(v)
+	}
+	return doc, nil
+}
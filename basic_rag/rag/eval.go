@@ -0,0 +1,327 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	es8retriever "github.com/cloudwego/eino-ext/components/retriever/es8"
+	"github.com/cloudwego/eino-ext/components/retriever/es8/search_mode"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"common/errs"
+	"common/fake"
+)
+
+// evalCase 一条评测用例
+type evalCase struct {
+	Query    string   `json:"query"`
+	Expected []string `json:"expected"` // 期望命中的条文编号
+}
+
+// evalConfig 一种待评测的检索配置
+type evalConfig struct {
+	Name      string
+	Retriever retriever.Retriever
+	Opts      []retriever.Option
+}
+
+// evalMetrics 一种配置在整个评测集上的平均指标
+type evalMetrics struct {
+	Recall float64
+	MRR    float64
+	NDCG   float64
+}
+
+// loadEvalCases 读取 JSONL 评测集
+func loadEvalCases(path string) ([]evalCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开评测集失败: %w", err)
+	}
+	defer f.Close()
+
+	var cases []evalCase
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var c evalCase
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("解析评测集第 %d 行失败: %w", line, err)
+		}
+		if c.Query == "" || len(c.Expected) == 0 {
+			return nil, fmt.Errorf("评测集第 %d 行缺少 query 或 expected", line)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取评测集失败: %w", err)
+	}
+	return cases, nil
+}
+
+// scoreRanking 计算单条查询的 recall@k、倒数排名与 nDCG@k，相关性按是否命中期望条文二值计算
+func scoreRanking(ranked []string, expected []string, k int) (recall, rr, ndcg float64) {
+	relevant := make(map[string]bool, len(expected))
+	for _, id := range expected {
+		relevant[id] = true
+	}
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	hits := 0
+	var dcg float64
+	seen := make(map[string]bool)
+	for i, id := range ranked {
+		if !relevant[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		hits++
+		if rr == 0 {
+			rr = 1 / float64(i+1)
+		}
+		dcg += 1 / math.Log2(float64(i+2))
+	}
+
+	var idcg float64
+	for i := 0; i < min(len(relevant), k); i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	recall = float64(hits) / float64(len(relevant))
+	if idcg > 0 {
+		ndcg = dcg / idcg
+	}
+	return recall, rr, ndcg
+}
+
+// evaluate 在评测集上运行一种检索配置
+func evaluate(ctx context.Context, conf evalConfig, cases []evalCase, k int) (*evalMetrics, error) {
+	m := &evalMetrics{}
+	for _, c := range cases {
+		docs, err := conf.Retriever.Retrieve(ctx, c.Query, append([]retriever.Option{retriever.WithTopK(k)}, conf.Opts...)...)
+		if err != nil {
+			return nil, errs.Wrap(errs.ErrRetrieval, fmt.Sprintf("%s 检索 %q", conf.Name, c.Query), err)
+		}
+
+		ranked := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			ranked = append(ranked, citationLabel(doc))
+		}
+		recall, rr, ndcg := scoreRanking(ranked, c.Expected, k)
+		m.Recall += recall
+		m.MRR += rr
+		m.NDCG += ndcg
+	}
+
+	n := float64(len(cases))
+	m.Recall, m.MRR, m.NDCG = m.Recall/n, m.MRR/n, m.NDCG/n
+	return m, nil
+}
+
+// buildEvalConfigs 构建待对比的检索配置：kNN、BM25、ES 混合（分数相加）、应用侧 RRF 与加权融合
+// fusionOpts 作用于两种应用侧融合配置，如 RRF 常数与两路权重
+func buildEvalConfigs(ctx context.Context, client *elasticsearch.Client, embedder embedding.Embedder, index string, k int, fusionOpts ...retriever.Option) ([]evalConfig, error) {
+	newES := func(mode es8retriever.SearchMode) (retriever.Retriever, error) {
+		return es8retriever.NewRetriever(ctx, &es8retriever.RetrieverConfig{
+			Client:       client,
+			Index:        index,
+			Embedding:    embedder,
+			TopK:         k,
+			SearchMode:   mode,
+			ResultParser: parseHit,
+		})
+	}
+
+	numCandidates := k * 5
+	knn, err := newES(search_mode.SearchModeApproximate(&search_mode.ApproximateConfig{
+		VectorFieldName: fieldContentVector,
+		K:               &k,
+		NumCandidates:   &numCandidates,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	bm25, err := newES(search_mode.SearchModeExactMatch(fieldContent))
+	if err != nil {
+		return nil, err
+	}
+	hybrid, err := newES(search_mode.SearchModeApproximate(&search_mode.ApproximateConfig{
+		QueryFieldName:  fieldContent,
+		VectorFieldName: fieldContentVector,
+		Hybrid:          true,
+		K:               &k,
+		NumCandidates:   &numCandidates,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	fusion, err := newFusionRetriever(ctx, &fusionRetrieverConfig{
+		Client:     client,
+		Index:      index,
+		Embedding:  embedder,
+		TopK:       k,
+		CandidateK: 2 * k,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []evalConfig{
+		{Name: "knn", Retriever: knn},
+		{Name: "bm25", Retriever: bm25},
+		{Name: "hybrid", Retriever: hybrid},
+		{Name: "hybrid+rrf", Retriever: fusion, Opts: append(fusionOpts[:len(fusionOpts):len(fusionOpts)], withFusionMethod(fusionRRF))},
+		{Name: "hybrid+weighted", Retriever: fusion, Opts: append(fusionOpts[:len(fusionOpts):len(fusionOpts)], withFusionMethod(fusionWeighted))},
+	}, nil
+}
+
+// buildMemoryEvalConfigs 在内存存储上构建待对比的检索配置：kNN、BM25、RRF 与加权融合
+func buildMemoryEvalConfigs(store *memoryStore, k int, fusionOpts ...retriever.Option) []evalConfig {
+	knn := newMemoryRetriever(store, memorySearchKNN, k)
+	bm25 := newMemoryRetriever(store, memorySearchBM25, k)
+	fusion := fuseRetrievers(
+		newMemoryRetriever(store, memorySearchBM25, 2*k),
+		newMemoryRetriever(store, memorySearchKNN, 2*k),
+		&fusionRetrieverConfig{TopK: k, CandidateK: 2 * k},
+	)
+
+	return []evalConfig{
+		{Name: "knn", Retriever: knn},
+		{Name: "bm25", Retriever: bm25},
+		{Name: "hybrid+rrf", Retriever: fusion, Opts: append(fusionOpts[:len(fusionOpts):len(fusionOpts)], withFusionMethod(fusionRRF))},
+		{Name: "hybrid+weighted", Retriever: fusion, Opts: append(fusionOpts[:len(fusionOpts):len(fusionOpts)], withFusionMethod(fusionWeighted))},
+	}
+}
+
+// EvalOptions 离线评测的参数
+type EvalOptions struct {
+	K        int    // 评测截断位置
+	Embedder string // 向量化方式：hash 本地哈希，llm 配置的向量模型
+	Dims     int    // hash 向量化时的向量维度
+}
+
+// Evaluate 在 rag.eval_queries 评测集上对比各检索配置，结果以表格写入 out
+// hash 向量化不调用任何在线模型；memory 后端无需 Elasticsearch；
+// llm 向量化使用配置的向量模型，向量经 embedding_cache 缓存，重复评测几乎不再调用模型
+func Evaluate(ctx context.Context, c *Config, opts *EvalOptions, out io.Writer) error {
+	if err := c.validateRetrieval(); err != nil {
+		return err
+	}
+
+	cases, err := loadEvalCases(c.RAG.EvalQueries)
+	if err != nil {
+		return err
+	}
+
+	docs, err := loadDocuments(ctx, c.RAG.DataPath)
+	if err != nil {
+		return err
+	}
+	chunkedDocs := chunkDocuments(docs)
+
+	var embedder embedding.Embedder
+	switch opts.Embedder {
+	case "hash":
+		embedder = &fake.Embedder{Dims: opts.Dims}
+	case "llm":
+		if err := c.LLM.Validate(); err != nil {
+			return err
+		}
+		e, closeEmbedder, err := newEmbedder(ctx, c)
+		if err != nil {
+			return errs.Wrap(errs.ErrModelUnavailable, "创建 embedder", err)
+		}
+		defer closeEmbedder()
+		embedder = e
+	default:
+		return fmt.Errorf("未知的 embedder: %s", opts.Embedder)
+	}
+	// 两种融合方式都参与评测，RRF 常数与两路权重取自 rag 配置
+	fusionOpts := []retriever.Option{withRRFK(c.RAG.RRFK), withFusionWeights(c.RAG.BM25Weight, c.RAG.VectorWeight)}
+
+	var configs []evalConfig
+	switch c.RAG.Store {
+	case "es":
+		configs, err = prepareESEval(ctx, &c.ES, embedder, chunkedDocs, opts.K, fusionOpts...)
+		if err != nil {
+			return errs.Wrap(errs.ErrRetrieval, "准备 ES 评测索引", err)
+		}
+	case "memory":
+		store, err := newMemoryStore(embedder, opts.Embedder, "")
+		if err != nil {
+			return err
+		}
+		if _, err := store.Store(ctx, chunkedDocs); err != nil {
+			return err
+		}
+		configs = buildMemoryEvalConfigs(store, opts.K, fusionOpts...)
+	default:
+		return fmt.Errorf("未知的向量存储后端: %s", c.RAG.Store)
+	}
+
+	fmt.Fprintf(out, "评测集 %s，共 %d 条查询，%d 个文本块\n\n", c.RAG.EvalQueries, len(cases), len(chunkedDocs))
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "配置\trecall@%d\tMRR\tnDCG@%d\n", opts.K, opts.K)
+	for _, conf := range configs {
+		m, err := evaluate(ctx, conf, cases, opts.K)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\n", conf.Name, m.Recall, m.MRR, m.NDCG)
+	}
+	return tw.Flush()
+}
+
+// prepareESEval 将文本块写入评测专用索引并构建 ES 上的检索配置
+func prepareESEval(ctx context.Context, es *ESConfig, embedder embedding.Embedder, docs []*schema.Document, k int, fusionOpts ...retriever.Option) ([]evalConfig, error) {
+	client, err := createESClient(es)
+	if err != nil {
+		return nil, err
+	}
+
+	// 评测专用索引别名，避免覆盖正式索引
+	evalIndexName := es.Index + "_eval"
+	target, err := ensureIndex(ctx, client, embedder, es, evalIndexName, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := storeDocuments(ctx, client, embedder, target, docs); err != nil {
+		return nil, err
+	}
+	if err := refreshIndex(client, target); err != nil {
+		return nil, err
+	}
+	if err := switchAlias(ctx, client, evalIndexName, target); err != nil {
+		return nil, err
+	}
+
+	return buildEvalConfigs(ctx, client, embedder, evalIndexName, k, fusionOpts...)
+}
+
+// refreshIndex 刷新索引，使刚写入的文档立即可被检索
+func refreshIndex(client *elasticsearch.Client, index string) error {
+	res, err := client.Indices.Refresh(client.Indices.Refresh.WithIndex(index))
+	if err != nil {
+		return fmt.Errorf("刷新索引失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("刷新索引失败: %s", res.String())
+	}
+	return nil
+}
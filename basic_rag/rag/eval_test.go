@@ -0,0 +1,38 @@
+package rag
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		name       string
+		ranked     []string
+		expected   []string
+		k          int
+		wantRecall float64
+		wantRR     float64
+		wantNDCG   float64
+	}{
+		{name: "首位命中", ranked: []string{"2.1", "2.2"}, expected: []string{"2.1"}, k: 10, wantRecall: 1, wantRR: 1, wantNDCG: 1},
+		{name: "第二位命中", ranked: []string{"2.2", "2.1"}, expected: []string{"2.1"}, k: 10, wantRecall: 1, wantRR: 0.5, wantNDCG: 1 / math.Log2(3)},
+		{name: "未命中", ranked: []string{"2.2", "2.3"}, expected: []string{"2.1"}, k: 10},
+		{name: "超出截断位置", ranked: []string{"2.2", "2.1"}, expected: []string{"2.1"}, k: 1},
+		{name: "部分召回", ranked: []string{"2.1", "2.3"}, expected: []string{"2.1", "2.2"}, k: 10, wantRecall: 0.5, wantRR: 1, wantNDCG: 1 / (1 + 1/math.Log2(3))},
+		{name: "重复结果只计一次", ranked: []string{"2.1", "2.1"}, expected: []string{"2.1", "2.2"}, k: 10, wantRecall: 0.5, wantRR: 1, wantNDCG: 1 / (1 + 1/math.Log2(3))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recall, rr, ndcg := scoreRanking(tt.ranked, tt.expected, tt.k)
+			if !almostEqual(recall, tt.wantRecall) || !almostEqual(rr, tt.wantRR) || !almostEqual(ndcg, tt.wantNDCG) {
+				t.Errorf("scoreRanking() = (%.4f, %.4f, %.4f), want (%.4f, %.4f, %.4f)",
+					recall, rr, ndcg, tt.wantRecall, tt.wantRR, tt.wantNDCG)
+			}
+		})
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,240 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	es8retriever "github.com/cloudwego/eino-ext/components/retriever/es8"
+	"github.com/cloudwego/eino-ext/components/retriever/es8/search_mode"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// 融合检索写入文档元数据的字段
+const (
+	metaBM25Score   = "bm25_score"   // BM25 原始分数
+	metaBM25Rank    = "bm25_rank"    // BM25 排名，从 1 开始
+	metaVectorScore = "vector_score" // 向量检索原始分数
+	metaVectorRank  = "vector_rank"  // 向量检索排名，从 1 开始
+)
+
+// fusionMethod 多路结果融合方式
+type fusionMethod string
+
+const (
+	// fusionRRF 倒数排名融合，只依赖排名，不受两路分数量纲影响
+	fusionRRF fusionMethod = "rrf"
+	// fusionWeighted 两路分数分别做 min-max 归一化后加权求和
+	fusionWeighted fusionMethod = "weighted"
+)
+
+// fusionOptions 融合检索的可选参数，可通过 retriever.Option 在每次检索时覆盖
+type fusionOptions struct {
+	Method       fusionMethod
+	RRFK         int     // RRF 常数 k，越大排名靠后的文档影响越大
+	BM25Weight   float64 // BM25 权重
+	VectorWeight float64 // 向量检索权重
+}
+
+// withFusionMethod 指定融合方式
+func withFusionMethod(method fusionMethod) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *fusionOptions) {
+		o.Method = method
+	})
+}
+
+// withRRFK 指定 RRF 常数 k
+func withRRFK(k int) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *fusionOptions) {
+		o.RRFK = k
+	})
+}
+
+// withFusionWeights 指定 BM25 与向量检索的权重
+func withFusionWeights(bm25, vector float64) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *fusionOptions) {
+		o.BM25Weight = bm25
+		o.VectorWeight = vector
+	})
+}
+
+// fusionRetrieverConfig 融合检索器配置
+type fusionRetrieverConfig struct {
+	Client     *elasticsearch.Client
+	Index      string
+	Embedding  embedding.Embedder
+	TopK       int // 融合后返回数量
+	CandidateK int // 每一路召回的候选数量
+	fusionOptions
+}
+
+// fusionRetriever 分别执行 BM25 与 kNN 检索，在应用侧融合排序，无需 ES 企业许可证
+type fusionRetriever struct {
+	bm25   retriever.Retriever
+	vector retriever.Retriever
+	config *fusionRetrieverConfig
+}
+
+// newFusionRetriever 创建基于 ES 的融合检索器
+func newFusionRetriever(ctx context.Context, conf *fusionRetrieverConfig) (*fusionRetriever, error) {
+	conf.setDefaults()
+
+	bm25, err := es8retriever.NewRetriever(ctx, &es8retriever.RetrieverConfig{
+		Client:       conf.Client,
+		Index:        conf.Index,
+		TopK:         conf.CandidateK,
+		SearchMode:   search_mode.SearchModeExactMatch(fieldContent),
+		ResultParser: parseHit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建 BM25 检索器失败: %w", err)
+	}
+
+	numCandidates := conf.CandidateK * 5
+	vector, err := es8retriever.NewRetriever(ctx, &es8retriever.RetrieverConfig{
+		Client:    conf.Client,
+		Index:     conf.Index,
+		Embedding: conf.Embedding,
+		TopK:      conf.CandidateK,
+		SearchMode: search_mode.SearchModeApproximate(&search_mode.ApproximateConfig{
+			VectorFieldName: fieldContentVector,
+			K:               &conf.CandidateK,
+			NumCandidates:   &numCandidates,
+		}),
+		ResultParser: parseHit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建向量检索器失败: %w", err)
+	}
+
+	return fuseRetrievers(bm25, vector, conf), nil
+}
+
+// fuseRetrievers 用任意 BM25 与向量检索器组合出融合检索器
+func fuseRetrievers(bm25, vector retriever.Retriever, conf *fusionRetrieverConfig) *fusionRetriever {
+	conf.setDefaults()
+	return &fusionRetriever{bm25: bm25, vector: vector, config: conf}
+}
+
+// setDefaults 填充未设置的配置项
+func (conf *fusionRetrieverConfig) setDefaults() {
+	if conf.TopK <= 0 {
+		conf.TopK = 3
+	}
+	if conf.Method == "" {
+		conf.Method = fusionRRF
+	}
+	if conf.RRFK <= 0 {
+		conf.RRFK = 60
+	}
+	if conf.BM25Weight == 0 && conf.VectorWeight == 0 {
+		conf.BM25Weight, conf.VectorWeight = 1, 1
+	}
+	if conf.CandidateK <= 0 {
+		conf.CandidateK = 20
+	}
+}
+
+// Retrieve 并发执行两路检索并融合
+func (r *fusionRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK := r.config.TopK
+	co := retriever.GetCommonOptions(&retriever.Options{TopK: &topK}, opts...)
+	fo := retriever.GetImplSpecificOptions(&fusionOptions{
+		Method:       r.config.Method,
+		RRFK:         r.config.RRFK,
+		BM25Weight:   r.config.BM25Weight,
+		VectorWeight: r.config.VectorWeight,
+	}, opts...)
+
+	var (
+		wg                sync.WaitGroup
+		bm25Docs, vecDocs []*schema.Document
+		bm25Err, vecErr   error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		bm25Docs, bm25Err = r.bm25.Retrieve(ctx, query)
+	}()
+	go func() {
+		defer wg.Done()
+		vecDocs, vecErr = r.vector.Retrieve(ctx, query)
+	}()
+	wg.Wait()
+
+	if bm25Err != nil {
+		return nil, fmt.Errorf("BM25 检索失败: %w", bm25Err)
+	}
+	if vecErr != nil {
+		return nil, fmt.Errorf("向量检索失败: %w", vecErr)
+	}
+
+	docs := fuseResults(bm25Docs, vecDocs, fo)
+	if len(docs) > *co.TopK {
+		docs = docs[:*co.TopK]
+	}
+	return docs, nil
+}
+
+// fuseResults 按融合方式合并两路结果，分数写入 Document.Score，原始分数与排名写入元数据
+func fuseResults(bm25Docs, vecDocs []*schema.Document, opts *fusionOptions) []*schema.Document {
+	merged := make(map[string]*schema.Document)
+	var order []string
+	fused := make(map[string]float64)
+
+	collect := func(docs []*schema.Document, scoreKey, rankKey string, weight float64) {
+		norm := minMaxNormalizer(docs)
+		for i, doc := range docs {
+			d, ok := merged[doc.ID]
+			if !ok {
+				d = &schema.Document{ID: doc.ID, Content: doc.Content, MetaData: map[string]any{}}
+				for k, v := range doc.MetaData {
+					d.MetaData[k] = v
+				}
+				merged[doc.ID] = d
+				order = append(order, doc.ID)
+			}
+			d.MetaData[scoreKey] = doc.Score()
+			d.MetaData[rankKey] = i + 1
+
+			switch opts.Method {
+			case fusionWeighted:
+				fused[doc.ID] += weight * norm(doc.Score())
+			default:
+				fused[doc.ID] += weight / float64(opts.RRFK+i+1)
+			}
+		}
+	}
+	collect(bm25Docs, metaBM25Score, metaBM25Rank, opts.BM25Weight)
+	collect(vecDocs, metaVectorScore, metaVectorRank, opts.VectorWeight)
+
+	docs := make([]*schema.Document, 0, len(order))
+	for _, id := range order {
+		docs = append(docs, merged[id].WithScore(fused[id]))
+	}
+	// 分数相同时保持先 BM25 后向量的出现顺序，结果稳定
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Score() > docs[j].Score()
+	})
+	return docs
+}
+
+// minMaxNormalizer 返回把分数映射到 [0,1] 的函数，所有分数相同时统一为 1
+func minMaxNormalizer(docs []*schema.Document) func(float64) float64 {
+	if len(docs) == 0 {
+		return func(float64) float64 { return 0 }
+	}
+	lo, hi := docs[0].Score(), docs[0].Score()
+	for _, doc := range docs[1:] {
+		lo = min(lo, doc.Score())
+		hi = max(hi, doc.Score())
+	}
+	if hi == lo {
+		return func(float64) float64 { return 1 }
+	}
+	return func(s float64) float64 { return (s - lo) / (hi - lo) }
+}
@@ -0,0 +1,103 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func scoredDocs(scores map[string]float64, ids ...string) []*schema.Document {
+	docs := make([]*schema.Document, len(ids))
+	for i, id := range ids {
+		docs[i] = (&schema.Document{ID: id, MetaData: map[string]any{}}).WithScore(scores[id])
+	}
+	return docs
+}
+
+func TestFuseResults(t *testing.T) {
+	bm25 := scoredDocs(map[string]float64{"a": 9, "b": 5, "c": 1}, "a", "b", "c")
+	vector := scoredDocs(map[string]float64{"b": 0.9, "c": 0.8, "d": 0.1}, "b", "c", "d")
+
+	tests := []struct {
+		name string
+		opts fusionOptions
+		want []string
+	}{
+		{name: "RRF 两路都靠前的文档优先", opts: fusionOptions{Method: fusionRRF, RRFK: 60, BM25Weight: 1, VectorWeight: 1}, want: []string{"b", "c", "a", "d"}},
+		{name: "加权融合偏向 BM25", opts: fusionOptions{Method: fusionWeighted, BM25Weight: 1, VectorWeight: 0.1}, want: []string{"a", "b", "c", "d"}},
+		{name: "加权融合偏向向量", opts: fusionOptions{Method: fusionWeighted, BM25Weight: 0.1, VectorWeight: 1}, want: []string{"b", "c", "a", "d"}},
+		{name: "只有向量", opts: fusionOptions{Method: fusionRRF, RRFK: 60, VectorWeight: 1}, want: []string{"b", "c", "d", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := fuseResults(bm25, vector, &tt.opts)
+			var got []string
+			for _, doc := range docs {
+				got = append(got, doc.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFuseResultsMetadata(t *testing.T) {
+	bm25 := scoredDocs(map[string]float64{"a": 3}, "a")
+	vector := scoredDocs(map[string]float64{"b": 0.7, "a": 0.5}, "b", "a")
+	docs := fuseResults(bm25, vector, &fusionOptions{Method: fusionRRF, RRFK: 60, BM25Weight: 1, VectorWeight: 1})
+
+	byID := map[string]*schema.Document{}
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	a := byID["a"].MetaData
+	if a[metaBM25Rank] != 1 || a[metaBM25Score] != 3.0 || a[metaVectorRank] != 2 || a[metaVectorScore] != 0.5 {
+		t.Errorf("a metadata = %v", a)
+	}
+	if _, ok := byID["b"].MetaData[metaBM25Rank]; ok {
+		t.Errorf("b 只被向量检索召回，不应有 BM25 排名: %v", byID["b"].MetaData)
+	}
+	// 原始文档不应被修改
+	if _, ok := bm25[0].MetaData[metaVectorRank]; ok {
+		t.Errorf("fuseResults 修改了输入文档的元数据")
+	}
+}
+
+func TestRAGConfigFusion(t *testing.T) {
+	c := DefaultConfig()
+	c.RAG.Fusion, c.RAG.BM25Weight, c.RAG.VectorWeight = "weighted", 1, 0.1
+	if err := c.validateRetrieval(); err != nil {
+		t.Fatalf("validateRetrieval() error = %v", err)
+	}
+	bm25 := scoredDocs(map[string]float64{"a": 9, "b": 5}, "a", "b")
+	vector := scoredDocs(map[string]float64{"b": 0.9, "a": 0.1}, "b", "a")
+	opts := c.RAG.fusionOptions()
+	if docs := fuseResults(bm25, vector, &opts); docs[0].ID != "a" {
+		t.Errorf("配置偏向 BM25 时首位为 %s, want a", docs[0].ID)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *RAGConfig)
+	}{
+		{name: "未知融合方式", modify: func(c *RAGConfig) { c.Fusion = "max" }},
+		{name: "RRF 常数为 0", modify: func(c *RAGConfig) { c.RRFK = 0 }},
+		{name: "权重同时为 0", modify: func(c *RAGConfig) { c.BM25Weight, c.VectorWeight = 0, 0 }},
+		{name: "权重为负", modify: func(c *RAGConfig) { c.VectorWeight = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(&c.RAG)
+			if err := c.validateRetrieval(); err == nil {
+				t.Error("validateRetrieval() error = nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ClaimSupport 单条论断的校验结果
+type ClaimSupport struct {
+	Claim     string  `json:"claim"`
+	Supported bool    `json:"supported"`
+	Score     float64 `json:"score"`    // 支持程度，0-1
+	Evidence  string  `json:"evidence"` // 最能支持该论断的文档引用标签
+}
+
+// Answer 一轮问答的结果
+type Answer struct {
+	Text string
+	// Grounding 依据校验结果，未开启校验或校验本身失败时为 nil
+	Grounding *GroundingResult
+}
+
+// GroundingResult 回答的依据校验结果
+type GroundingResult struct {
+	Score  float64        `json:"score"` // 被支持的论断占比
+	Claims []ClaimSupport `json:"claims"`
+}
+
+// Unsupported 返回缺乏依据的论断
+func (r *GroundingResult) Unsupported() []string {
+	var claims []string
+	for _, c := range r.Claims {
+		if !c.Supported {
+			claims = append(claims, c.Claim)
+		}
+	}
+	return claims
+}
+
+// groundingChecker 校验论断是否被检索文档支持，返回结果与 claims 一一对应
+type groundingChecker interface {
+	Check(ctx context.Context, claims []string, docs []*schema.Document) ([]ClaimSupport, error)
+}
+
+// newGroundingChecker 按 grounding.mode 创建校验器：lexical、llm
+func newGroundingChecker(c *GroundingConfig, chatModel model.BaseChatModel) (groundingChecker, error) {
+	switch c.Mode {
+	case "lexical":
+		return &lexicalGrounding{Threshold: c.LexicalThreshold}, nil
+	case "llm":
+		if chatModel == nil {
+			return nil, fmt.Errorf("llm 校验需要 chat model")
+		}
+		return &llmGrounding{ChatModel: chatModel}, nil
+	default:
+		return nil, fmt.Errorf("未知的校验方式: %s", c.Mode)
+	}
+}
+
+// claimSplitter 按句末标点与换行拆分论断
+var claimSplitter = regexp.MustCompile(`[。！？!?；;\n]+`)
+
+// splitClaims 把回答拆分为论断，去掉引用标记与过短的片段
+func splitClaims(answer string) []string {
+	answer = citationPattern.ReplaceAllString(answer, "")
+	var claims []string
+	for _, part := range claimSplitter.Split(answer, -1) {
+		part = strings.TrimSpace(strings.Trim(part, " -*#>·"))
+		// 过短的片段多为标题或连接语，不作为论断
+		if len([]rune(part)) < 6 {
+			continue
+		}
+		claims = append(claims, part)
+	}
+	return claims
+}
+
+// verifyAnswer 拆分回答并逐条校验
+func verifyAnswer(ctx context.Context, checker groundingChecker, answer string, docs []*schema.Document) (*GroundingResult, error) {
+	claims := splitClaims(answer)
+	if len(claims) == 0 {
+		return &GroundingResult{Score: 1}, nil
+	}
+
+	supports, err := checker.Check(ctx, claims, docs)
+	if err != nil {
+		return nil, err
+	}
+	if len(supports) != len(claims) {
+		return nil, fmt.Errorf("校验结果数量 %d 与论断数量 %d 不一致", len(supports), len(claims))
+	}
+
+	supported := 0
+	for _, s := range supports {
+		if s.Supported {
+			supported++
+		}
+	}
+	return &GroundingResult{Score: float64(supported) / float64(len(claims)), Claims: supports}, nil
+}
+
+// lexicalGrounding 字面校验：论断的字符二元组被某个文档覆盖的比例
+type lexicalGrounding struct {
+	Threshold float64
+}
+
+func (g *lexicalGrounding) Check(_ context.Context, claims []string, docs []*schema.Document) ([]ClaimSupport, error) {
+	docGrams := make([]map[string]struct{}, len(docs))
+	for i, doc := range docs {
+		docGrams[i] = bigrams(doc.Content)
+	}
+
+	supports := make([]ClaimSupport, len(claims))
+	for i, claim := range claims {
+		supports[i] = ClaimSupport{Claim: claim}
+		grams := bigrams(claim)
+		if len(grams) == 0 {
+			continue
+		}
+		for j, dg := range docGrams {
+			hit := 0
+			for g := range grams {
+				if _, ok := dg[g]; ok {
+					hit++
+				}
+			}
+			if score := float64(hit) / float64(len(grams)); score > supports[i].Score {
+				supports[i].Score = score
+				supports[i].Evidence = citationLabel(docs[j])
+			}
+		}
+		supports[i].Supported = supports[i].Score >= g.Threshold
+	}
+	return supports, nil
+}
+
+// llmGrounding 让大模型判断每条论断能否由文档推出
+type llmGrounding struct {
+	ChatModel model.BaseChatModel
+}
+
+func (g *llmGrounding) Check(ctx context.Context, claims []string, docs []*schema.Document) ([]ClaimSupport, error) {
+	var b strings.Builder
+	b.WriteString("文档：\n")
+	b.WriteString(buildChatContext(docs))
+	b.WriteString("论断：\n")
+	for i, claim := range claims {
+		fmt.Fprintf(&b, "(%d) %s\n", i, claim)
+	}
+
+	resp, err := g.ChatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("你是事实核查员。逐条判断论断能否由给定文档直接推出，不得使用文档以外的知识。" +
+			"只输出 JSON 数组，例如 [{\"index\":0,\"supported\":true,\"score\":0.9,\"evidence\":\"2.5.1\"}]，" +
+			"evidence 为依据的文档编号，不被支持时留空。"),
+		schema.UserMessage(b.String()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("模型校验失败: %w", err)
+	}
+
+	type llmSupport struct {
+		Index     int     `json:"index"`
+		Supported bool    `json:"supported"`
+		Score     float64 `json:"score"`
+		Evidence  string  `json:"evidence"`
+	}
+	items, err := decodeJSONArray[llmSupport](resp.Content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模型校验结果失败: %w", err)
+	}
+
+	supports := make([]ClaimSupport, len(claims))
+	for i, claim := range claims {
+		supports[i] = ClaimSupport{Claim: claim}
+	}
+	for _, item := range items {
+		if item.Index < 0 || item.Index >= len(supports) {
+			continue
+		}
+		supports[item.Index].Supported = item.Supported
+		supports[item.Index].Score = item.Score
+		supports[item.Index].Evidence = item.Evidence
+	}
+	return supports, nil
+}
+
+// chatWithGrounding 生成回答并按 grounding.mode 校验依据，依据不足时标注或重新生成
+// 校验本身失败时只记录日志并返回不带校验结果的回答，生成失败时返回错误
+func chatWithGrounding(ctx context.Context, chatModel model.BaseChatModel, grounding *GroundingConfig, messages []*schema.Message, docs []*schema.Document, w io.Writer) (*Answer, error) {
+	text, err := chat(ctx, chatModel, messages, w)
+	if err != nil {
+		return nil, err
+	}
+	if grounding.Mode == "" {
+		return &Answer{Text: text}, nil
+	}
+
+	checker, err := newGroundingChecker(grounding, chatModel)
+	if err != nil {
+		log.Printf("创建依据校验器失败: %v", err)
+		return &Answer{Text: text}, nil
+	}
+
+	result, err := verifyAnswer(ctx, checker, text, docs)
+	if err != nil {
+		log.Printf("依据校验失败: %v", err)
+		return &Answer{Text: text}, nil
+	}
+	printGrounding(w, result)
+	if result.Score >= grounding.Threshold || !grounding.Regenerate {
+		return &Answer{Text: text, Grounding: result}, nil
+	}
+
+	// 指出缺乏依据的论断，要求仅依据文档重新回答
+	retry := append(messages[:len(messages):len(messages)],
+		schema.AssistantMessage(text, nil),
+		schema.UserMessage("以下内容在获取的文档中找不到依据：\n- "+strings.Join(result.Unsupported(), "\n- ")+
+			"\n请仅依据获取的文档重新回答，文档未提及的内容请直接说明。"),
+	)
+	fmt.Fprintln(w, "\n回答依据不足，重新生成：")
+	if text, err = chat(ctx, chatModel, retry, w); err != nil {
+		return nil, err
+	}
+
+	if result, err = verifyAnswer(ctx, checker, text, docs); err != nil {
+		log.Printf("依据校验失败: %v", err)
+		return &Answer{Text: text}, nil
+	}
+	printGrounding(w, result)
+	return &Answer{Text: text, Grounding: result}, nil
+}
+
+// printGrounding 输出依据校验结果
+func printGrounding(w io.Writer, result *GroundingResult) {
+	fmt.Fprintf(w, "\n\n依据校验: 支持率 %.0f%%（%d 条论断）\n", result.Score*100, len(result.Claims))
+	for _, c := range result.Claims {
+		if !c.Supported {
+			fmt.Fprintf(w, "  [依据不足] %s\n", c.Claim)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+	"common/fake"
+)
+
+func TestSplitClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   []string
+	}{
+		{name: "空回答", answer: ""},
+		{name: "按句号拆分并去掉引用", answer: "风寒束表证以恶寒重为主症[2.3.1]。治宜辛温解表散寒！", want: []string{"风寒束表证以恶寒重为主症", "治宜辛温解表散寒"}},
+		{name: "过滤过短片段与列表符号", answer: "总结：\n- 发热轻而恶寒重\n好的。", want: []string{"发热轻而恶寒重"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitClaims(tt.answer); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitClaims() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexicalGrounding(t *testing.T) {
+	docs := []*schema.Document{{ID: "2.3.1", Content: "风寒束表证 风寒之邪外袭肌表，以恶寒重、发热轻为主症。"}}
+	result, err := verifyAnswer(context.Background(), &lexicalGrounding{Threshold: 0.5},
+		"风寒之邪外袭肌表。患者宜多饮热水并卧床休息。", docs)
+	if err != nil {
+		t.Fatalf("verifyAnswer() error = %v", err)
+	}
+	if result.Score != 0.5 {
+		t.Errorf("Score = %v, want 0.5", result.Score)
+	}
+	if got := result.Unsupported(); !reflect.DeepEqual(got, []string{"患者宜多饮热水并卧床休息"}) {
+		t.Errorf("Unsupported() = %q", got)
+	}
+	if result.Claims[0].Evidence != "2.3.1" {
+		t.Errorf("Evidence = %q, want 2.3.1", result.Claims[0].Evidence)
+	}
+}
+
+func TestLLMGrounding(t *testing.T) {
+	docs := []*schema.Document{{ID: "2.3.1", Content: "风寒束表证 风寒之邪外袭肌表，以恶寒重、发热轻为主症。"}}
+	reply := `论断 (0) 见 [2.3.1]：[{"index":0,"supported":true,"score":0.9,"evidence":"2.3.1"},{"index":1,"supported":false,"score":0}]`
+	g := &llmGrounding{ChatModel: fake.NewChatModel(fake.Reply(reply))}
+
+	result, err := verifyAnswer(context.Background(), g, "风寒之邪外袭肌表。患者宜多饮热水并卧床休息。", docs)
+	if err != nil {
+		t.Fatalf("verifyAnswer() error = %v", err)
+	}
+	if result.Score != 0.5 || result.Claims[0].Evidence != "2.3.1" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestChatWithGrounding(t *testing.T) {
+	docs := []*schema.Document{{ID: "2.3.1", Content: "风寒束表证 风寒之邪外袭肌表，以恶寒重、发热轻为主症。"}}
+	grounded := "风寒之邪外袭肌表，以恶寒重、发热轻为主症[2.3.1]。"
+	unsupported := "患者宜多饮热水并卧床休息。"
+
+	tests := []struct {
+		name       string
+		grounding  GroundingConfig
+		replies    []string
+		wantAnswer string
+		wantScore  float64 // 小于 0 表示不应有校验结果
+		wantCalls  int
+	}{
+		{name: "关闭校验", replies: []string{unsupported}, wantAnswer: unsupported, wantScore: -1, wantCalls: 1},
+		{name: "依据充分", grounding: GroundingConfig{Mode: "lexical", Threshold: 0.8, Regenerate: true, LexicalThreshold: 0.5}, replies: []string{grounded}, wantAnswer: grounded, wantScore: 1, wantCalls: 1},
+		{name: "只标注不重试", grounding: GroundingConfig{Mode: "lexical", Threshold: 0.8, LexicalThreshold: 0.5}, replies: []string{unsupported}, wantAnswer: unsupported, wantScore: 0, wantCalls: 1},
+		{name: "依据不足重新生成", grounding: GroundingConfig{Mode: "lexical", Threshold: 0.8, Regenerate: true, LexicalThreshold: 0.5}, replies: []string{unsupported, grounded}, wantAnswer: grounded, wantScore: 1, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &fake.ChatModel{ChunkSize: 4}
+			for _, r := range tt.replies {
+				cm.Replies = append(cm.Replies, fake.Reply(r))
+			}
+
+			var out strings.Builder
+			answer, err := chatWithGrounding(context.Background(), cm, &tt.grounding, []*schema.Message{schema.UserMessage("风寒感冒有什么表现？")}, docs, &out)
+			if err != nil {
+				t.Fatalf("chatWithGrounding() error = %v", err)
+			}
+			if answer.Text != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", answer.Text, tt.wantAnswer)
+			}
+			switch {
+			case tt.wantScore < 0 && answer.Grounding != nil:
+				t.Errorf("Grounding = %+v, want nil", answer.Grounding)
+			case tt.wantScore >= 0 && (answer.Grounding == nil || answer.Grounding.Score != tt.wantScore):
+				t.Errorf("Grounding = %+v, want score %v", answer.Grounding, tt.wantScore)
+			}
+			calls := cm.Calls()
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("model called %d times, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantCalls == 2 {
+				// 重试时应把缺乏依据的论断反馈给模型
+				last := calls[1].Input[len(calls[1].Input)-1]
+				if !strings.Contains(last.Content, "患者宜多饮热水并卧床休息") {
+					t.Errorf("retry prompt = %q", last.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestChatWithGroundingModelError(t *testing.T) {
+	grounding := &GroundingConfig{Mode: "lexical", Threshold: 0.8, Regenerate: true, LexicalThreshold: 0.5}
+
+	tests := []struct {
+		name    string
+		replies []string
+	}{
+		{name: "首次生成失败"},
+		{name: "重新生成失败", replies: []string{"患者宜多饮热水并卧床休息。"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := fake.NewChatModel()
+			for _, r := range tt.replies {
+				cm.Replies = append(cm.Replies, fake.Reply(r))
+			}
+			docs := []*schema.Document{{ID: "2.3.1", Content: "风寒束表证"}}
+			_, err := chatWithGrounding(context.Background(), cm, grounding, []*schema.Message{schema.UserMessage("问题")}, docs, io.Discard)
+			if !errors.Is(err, errs.ErrModelUnavailable) || !errors.Is(err, fake.ErrScriptExhausted) {
+				t.Errorf("error = %v, want ErrModelUnavailable wrapping ErrScriptExhausted", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+)
+
+// indexManifest 记录已索引文本块的内容哈希，用于增量索引
+type indexManifest struct {
+	Index  string            `json:"index"`  // 对应的 es 索引
+	Chunks map[string]string `json:"chunks"` // 文本块 ID -> 内容哈希
+}
+
+// loadManifest 读取本地清单，文件不存在或索引不一致时返回空清单
+func loadManifest(path, index string) (*indexManifest, error) {
+	m := &indexManifest{Index: index, Chunks: map[string]string{}}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取索引清单失败: %w", err)
+	}
+
+	var stored indexManifest
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, fmt.Errorf("解析索引清单失败: %w", err)
+	}
+	if stored.Index != index || stored.Chunks == nil {
+		return m, nil
+	}
+	return &stored, nil
+}
+
+// save 写回本地清单，先写临时文件再替换，避免中途失败留下半个文件
+func (m *indexManifest) save(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化索引清单失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("写入索引清单失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入索引清单失败: %w", err)
+	}
+	return nil
+}
+
+// diff 对比当前文本块，返回需要重新索引的文本块和已失效的文本块 ID
+func (m *indexManifest) diff(docs []*schema.Document) (changed []*schema.Document, stale []string, hashes map[string]string) {
+	hashes = make(map[string]string, len(docs))
+	for _, doc := range docs {
+		h := contentHash(doc)
+		hashes[doc.ID] = h
+		if m.Chunks[doc.ID] != h {
+			changed = append(changed, doc)
+		}
+	}
+
+	for id := range m.Chunks {
+		if _, ok := hashes[id]; !ok {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+
+	return changed, stale, hashes
+}
+
+// contentHash 计算文本块哈希，元数据变化同样需要重新索引
+func contentHash(doc *schema.Document) string {
+	h := sha256.New()
+	h.Write([]byte(doc.Content))
+	if len(doc.MetaData) > 0 {
+		// json 序列化 map 时按 key 排序，结果稳定
+		meta, _ := json.Marshal(doc.MetaData)
+		h.Write([]byte{0})
+		h.Write(meta)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// indexDocCount 返回 es 索引中的文档数，索引不存在时返回 0
+func indexDocCount(client *elasticsearch.Client, index string) (int, error) {
+	res, err := client.Count(client.Count.WithIndex(index))
+	if err != nil {
+		return 0, fmt.Errorf("查询文档数失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 404 {
+		return 0, nil
+	}
+	if res.IsError() {
+		return 0, fmt.Errorf("ES 返回错误: %s", res.String())
+	}
+
+	var body struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("解析文档数失败: %w", err)
+	}
+	return body.Count, nil
+}
+
+// indexExists 判断 es 索引是否存在
+func indexExists(client *elasticsearch.Client, index string) (bool, error) {
+	res, err := client.Indices.Exists([]string{index})
+	if err != nil {
+		return false, fmt.Errorf("查询索引失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case 200:
+		return true, nil
+	case 404:
+		return false, nil
+	default:
+		return false, fmt.Errorf("ES 返回错误: %s", res.String())
+	}
+}
+
+// deleteDocuments 批量删除 es 中已失效的文本块
+func deleteDocuments(ctx context.Context, client *elasticsearch.Client, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Index:  index,
+		Client: client,
+	})
+	if err != nil {
+		return fmt.Errorf("创建批量删除器失败: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []string
+	)
+	for _, id := range ids {
+		err := bi.Add(ctx, esutil.BulkIndexerItem{
+			Action:     "delete",
+			DocumentID: id,
+			OnFailure: func(_ context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+				// 文档本来就不存在时无需处理
+				if err == nil && res.Status == 404 {
+					return
+				}
+				mu.Lock()
+				failed = append(failed, item.DocumentID)
+				mu.Unlock()
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("删除文档失败: %w", err)
+		}
+	}
+
+	if err := bi.Close(ctx); err != nil {
+		return fmt.Errorf("删除文档失败: %w", err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("删除文档失败: %v", failed)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestContentHash(t *testing.T) {
+	base := &schema.Document{ID: "2.3.1", Content: "风寒束表证", MetaData: map[string]any{metaClauseID: "2.3.1", metaDepth: 3}}
+
+	tests := []struct {
+		name string
+		doc  *schema.Document
+		same bool
+	}{
+		{name: "内容与元数据相同", doc: &schema.Document{ID: "other", Content: "风寒束表证", MetaData: map[string]any{metaDepth: 3, metaClauseID: "2.3.1"}}, same: true},
+		{name: "内容变化", doc: &schema.Document{Content: "风热犯表证", MetaData: map[string]any{metaClauseID: "2.3.1", metaDepth: 3}}},
+		{name: "元数据变化", doc: &schema.Document{Content: "风寒束表证", MetaData: map[string]any{metaClauseID: "2.3.2", metaDepth: 3}}},
+		{name: "没有元数据", doc: &schema.Document{Content: "风寒束表证"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentHash(tt.doc) == contentHash(base); got != tt.same {
+				t.Errorf("hash equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestManifestDiff(t *testing.T) {
+	kept := &schema.Document{ID: "2.3.1", Content: "风寒束表证"}
+	edited := &schema.Document{ID: "2.3.2", Content: "风热犯表证（修订）"}
+	added := &schema.Document{ID: "2.5.1", Content: "肝郁气滞证"}
+
+	m := &indexManifest{Index: "tcm_v1", Chunks: map[string]string{
+		"2.3.1": contentHash(kept),
+		"2.3.2": contentHash(&schema.Document{Content: "风热犯表证"}),
+		"2.4.1": "removed",
+		"1.1":   "removed",
+	}}
+
+	changed, stale, hashes := m.diff([]*schema.Document{kept, edited, added})
+
+	var changedIDs []string
+	for _, doc := range changed {
+		changedIDs = append(changedIDs, doc.ID)
+	}
+	if want := []string{"2.3.2", "2.5.1"}; !reflect.DeepEqual(changedIDs, want) {
+		t.Errorf("changed = %v, want %v", changedIDs, want)
+	}
+	if want := []string{"1.1", "2.4.1"}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("stale = %v, want %v", stale, want)
+	}
+	if len(hashes) != 3 || hashes["2.5.1"] != contentHash(added) {
+		t.Errorf("hashes = %v", hashes)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+
+	saved := &indexManifest{Index: "tcm_v1", Chunks: map[string]string{"2.3.1": "a", "2.3.2": "b"}}
+	if err := saved.save(path); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("临时文件未被替换: %v", err)
+	}
+
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		index   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "往返读写", path: path, index: "tcm_v1", want: saved.Chunks},
+		{name: "文件不存在", path: filepath.Join(dir, "missing.json"), index: "tcm_v1", want: map[string]string{}},
+		{name: "索引不一致", path: path, index: "tcm_v2", want: map[string]string{}},
+		{name: "文件损坏", path: broken, index: "tcm_v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := loadManifest(tt.path, tt.index)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("loadManifest() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadManifest() error = %v", err)
+			}
+			if m.Index != tt.index || !reflect.DeepEqual(m.Chunks, tt.want) {
+				t.Errorf("loadManifest() = %+v, want index %s chunks %v", m, tt.index, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// versionedIndexName 返回别名在当前版本下的实际索引名
+func (c *ESConfig) versionedIndexName(alias string) string {
+	return fmt.Sprintf("%s_v%d", alias, c.MappingVersion)
+}
+
+// buildIndexMapping 构建索引 mapping
+func (c *ESConfig) buildIndexMapping(dims int) map[string]any {
+	content := map[string]any{
+		"type":     "text",
+		"analyzer": c.Analyzer,
+	}
+	if c.SearchAnalyzer != "" {
+		content["search_analyzer"] = c.SearchAnalyzer
+	}
+
+	return map[string]any{
+		"mappings": map[string]any{
+			"dynamic": "false",
+			"properties": map[string]any{
+				fieldContent: content,
+				fieldContentVector: map[string]any{
+					"type":       "dense_vector",
+					"dims":       dims,
+					"index":      true,
+					"similarity": c.Similarity,
+				},
+				"id":               map[string]any{"type": "keyword"},
+				metaSource:         map[string]any{"type": "keyword"},
+				metaParagraphIndex: map[string]any{"type": "keyword"},
+				metaClauseID:       map[string]any{"type": "keyword"},
+				metaParentClause:   map[string]any{"type": "keyword"},
+				metaDepth:          map[string]any{"type": "integer"},
+				metaSyndromeName: map[string]any{
+					"type":     "text",
+					"analyzer": c.Analyzer,
+					"fields": map[string]any{
+						"keyword": map[string]any{"type": "keyword"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// embeddingDims 通过向量化一段探测文本获取向量维度
+func embeddingDims(ctx context.Context, embedder embedding.Embedder) (int, error) {
+	vectors, err := embedder.EmbedStrings(ctx, []string{"维度探测"})
+	if err != nil {
+		return 0, fmt.Errorf("获取向量维度失败: %w", err)
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return 0, fmt.Errorf("获取向量维度失败: embedder 返回空向量")
+	}
+	return len(vectors[0]), nil
+}
+
+// ensureIndex 确保别名对应的当前版本索引存在，返回实际索引名
+// 索引已存在时从其 mapping 读取向量维度，不调用 embedder；创建索引时 dims 为 0 才探测一次 embedder
+func ensureIndex(ctx context.Context, client *elasticsearch.Client, embedder embedding.Embedder, c *ESConfig, alias string, dims int) (string, error) {
+	target := c.versionedIndexName(alias)
+	exists, err := indexExists(client, target)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		current, err := indexVectorDims(client, target)
+		if err != nil {
+			return "", err
+		}
+		if dims > 0 && current != dims {
+			return "", fmt.Errorf("索引 %s 向量维度为 %d，llm.embedding_dims 为 %d，请递增 es.mapping_version 重建索引", target, current, dims)
+		}
+		return target, nil
+	}
+
+	if dims == 0 {
+		if dims, err = embeddingDims(ctx, embedder); err != nil {
+			return "", err
+		}
+	}
+	body, err := json.Marshal(c.buildIndexMapping(dims))
+	if err != nil {
+		return "", fmt.Errorf("序列化 mapping 失败: %w", err)
+	}
+	res, err := client.Indices.Create(target,
+		client.Indices.Create.WithContext(ctx),
+		client.Indices.Create.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return "", fmt.Errorf("创建索引失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return "", fmt.Errorf("创建索引失败: %s", res.String())
+	}
+
+	log.Printf("  - 创建索引 %s（向量维度 %d，分词器 %s）", target, dims, c.Analyzer)
+	return target, nil
+}
+
+// indexVectorDims 读取已有索引的向量维度
+func indexVectorDims(client *elasticsearch.Client, index string) (int, error) {
+	res, err := client.Indices.GetMapping(client.Indices.GetMapping.WithIndex(index))
+	if err != nil {
+		return 0, fmt.Errorf("查询 mapping 失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, fmt.Errorf("查询 mapping 失败: %s", res.String())
+	}
+
+	var body map[string]struct {
+		Mappings struct {
+			Properties map[string]struct {
+				Dims int `json:"dims"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("解析 mapping 失败: %w", err)
+	}
+
+	return body[index].Mappings.Properties[fieldContentVector].Dims, nil
+}
+
+// aliasTargets 返回别名当前指向的索引
+func aliasTargets(client *elasticsearch.Client, alias string) ([]string, error) {
+	res, err := client.Indices.GetAlias(client.Indices.GetAlias.WithName(alias))
+	if err != nil {
+		return nil, fmt.Errorf("查询别名失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("查询别名失败: %s", res.String())
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析别名失败: %w", err)
+	}
+	targets := make([]string, 0, len(body))
+	for index := range body {
+		targets = append(targets, index)
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// switchAlias 原子地将别名切换到目标索引
+// 旧版本索引保留以便回滚；若存在与别名同名的旧索引（自动创建的），一并删除
+func switchAlias(ctx context.Context, client *elasticsearch.Client, alias, target string) error {
+	targets, err := aliasTargets(client, alias)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 1 && targets[0] == target {
+		return nil
+	}
+
+	var actions []map[string]any
+	for _, old := range targets {
+		if old == target {
+			continue
+		}
+		actions = append(actions, map[string]any{"remove": map[string]any{"index": old, "alias": alias}})
+	}
+
+	if len(targets) == 0 {
+		legacy, err := indexExists(client, alias)
+		if err != nil {
+			return err
+		}
+		if legacy {
+			log.Printf("  - 删除自动创建的旧索引 %s", alias)
+			actions = append(actions, map[string]any{"remove_index": map[string]any{"index": alias}})
+		}
+	}
+	actions = append(actions, map[string]any{"add": map[string]any{"index": target, "alias": alias}})
+
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("序列化别名操作失败: %w", err)
+	}
+	res, err := client.Indices.UpdateAliases(bytes.NewReader(body), client.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("切换别名失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("切换别名失败: %s", b)
+	}
+
+	log.Printf("  - 别名 %s 已切换到 %s（原指向 %v）", alias, target, targets)
+	return nil
+}
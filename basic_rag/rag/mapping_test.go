@@ -0,0 +1,136 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"common/fake"
+)
+
+func TestBuildIndexMapping(t *testing.T) {
+	c := &ESConfig{Index: "tcm", MappingVersion: 3, Analyzer: "ik_max_word", SearchAnalyzer: "ik_smart", Similarity: "cosine"}
+
+	if got := c.versionedIndexName(c.Index); got != "tcm_v3" {
+		t.Errorf("versionedIndexName() = %s, want tcm_v3", got)
+	}
+
+	mappings := c.buildIndexMapping(1024)["mappings"].(map[string]any)
+	if mappings["dynamic"] != "false" {
+		t.Errorf("dynamic = %v, want false", mappings["dynamic"])
+	}
+	props := mappings["properties"].(map[string]any)
+
+	content := props[fieldContent].(map[string]any)
+	if content["analyzer"] != "ik_max_word" || content["search_analyzer"] != "ik_smart" {
+		t.Errorf("content = %v", content)
+	}
+	vector := props[fieldContentVector].(map[string]any)
+	if vector["type"] != "dense_vector" || vector["dims"] != 1024 || vector["similarity"] != "cosine" {
+		t.Errorf("content_vector = %v", vector)
+	}
+	for _, key := range []string{metaSource, metaParagraphIndex, metaClauseID} {
+		if typ := props[key].(map[string]any)["type"]; typ != "keyword" {
+			t.Errorf("%s type = %v, want keyword", key, typ)
+		}
+	}
+
+	// 未配置查询分词器时不写 search_analyzer
+	c.SearchAnalyzer = ""
+	content = c.buildIndexMapping(8)["mappings"].(map[string]any)["properties"].(map[string]any)[fieldContent].(map[string]any)
+	if _, ok := content["search_analyzer"]; ok {
+		t.Errorf("content = %v, want no search_analyzer", content)
+	}
+}
+
+// countingEmbedder 记录向量化调用次数
+type countingEmbedder struct {
+	fake.Embedder
+	calls atomic.Int32
+}
+
+func (e *countingEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	e.calls.Add(1)
+	return e.Embedder.EmbedStrings(ctx, texts, opts...)
+}
+
+// newFakeES 模拟 ES 的索引查询与创建接口，existing 为已存在索引的向量维度
+func newFakeES(t *testing.T, existing map[string]int, created *string) *elasticsearch.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		index := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[0]
+		dims, ok := existing[index]
+		switch {
+		case r.Method == http.MethodHead && ok:
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/_mapping"):
+			fmt.Fprintf(w, `{%q:{"mappings":{"properties":{%q:{"type":"dense_vector","dims":%d}}}}}`, index, fieldContentVector, dims)
+		case r.Method == http.MethodPut:
+			*created = index
+			fmt.Fprintf(w, `{"acknowledged":true,"index":%q}`, index)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestEnsureIndex(t *testing.T) {
+	c := &ESConfig{MappingVersion: 2, Analyzer: "cjk", Similarity: "cosine"}
+
+	tests := []struct {
+		name        string
+		existing    map[string]int
+		dims        int
+		wantCreated string
+		wantProbes  int32
+		wantErr     bool
+	}{
+		{name: "已有索引不探测", existing: map[string]int{"tcm_v2": 64}, wantProbes: 0},
+		{name: "已有索引与配置一致", existing: map[string]int{"tcm_v2": 64}, dims: 64, wantProbes: 0},
+		{name: "已有索引与配置不一致", existing: map[string]int{"tcm_v2": 64}, dims: 128, wantErr: true},
+		{name: "创建索引使用配置维度", dims: 64, wantCreated: "tcm_v2", wantProbes: 0},
+		{name: "创建索引时探测维度", wantCreated: "tcm_v2", wantProbes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created string
+			client := newFakeES(t, tt.existing, &created)
+			embedder := &countingEmbedder{Embedder: fake.Embedder{Dims: 64}}
+
+			target, err := ensureIndex(context.Background(), client, embedder, c, "tcm", tt.dims)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ensureIndex() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ensureIndex() error = %v", err)
+			}
+			if target != "tcm_v2" || created != tt.wantCreated {
+				t.Errorf("target = %s, created = %q, want tcm_v2, %q", target, created, tt.wantCreated)
+			}
+			if got := embedder.calls.Load(); got != tt.wantProbes {
+				t.Errorf("embedder calls = %d, want %d", got, tt.wantProbes)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// memoryEntry 内存存储中的一个文本块
+type memoryEntry struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	MetaData map[string]any `json:"meta_data"`
+	Vector   []float64      `json:"vector"`
+	Hash     string         `json:"hash"` // 内容哈希，用于增量索引
+
+	terms map[string]int // 词频，加载时重建，不落盘
+	size  int            // 词数
+}
+
+// memoryFile 持久化文件的格式，记录生成向量的模型与维度
+type memoryFile struct {
+	EmbeddingModel string         `json:"embedding_model"`
+	Dims           int            `json:"dims"`
+	Entries        []*memoryEntry `json:"entries"`
+}
+
+// memoryStore 进程内向量存储：暴力余弦检索 + 内存 BM25，可选持久化到文件
+type memoryStore struct {
+	mu        sync.RWMutex
+	path      string // 持久化文件路径，为空时不落盘
+	embedder  embedding.Embedder
+	model     string // 向量模型，与文件中记录的不一致时重建
+	dims      int    // 已存储向量的维度，没有向量时为 0
+	batchSize int
+
+	entries map[string]*memoryEntry
+	df      map[string]int // 文档频率
+	total   int            // 所有文本块词数之和
+}
+
+// newMemoryStore 创建内存存储，path 不为空且文件存在时从文件加载
+// 文件由其它向量模型生成时不加载，下次同步时全部重新向量化
+func newMemoryStore(embedder embedding.Embedder, model, path string) (*memoryStore, error) {
+	s := &memoryStore{
+		path:      path,
+		embedder:  embedder,
+		model:     model,
+		batchSize: 10,
+		entries:   map[string]*memoryEntry{},
+		df:        map[string]int{},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取内存存储文件失败: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		log.Printf("  - %s 为旧格式，没有记录向量模型与维度，将重建内存存储", path)
+		return s, nil
+	}
+	var f memoryFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("解析内存存储文件失败: %w", err)
+	}
+	if f.EmbeddingModel != model {
+		log.Printf("  - %s 由向量模型 %q 生成，当前为 %q，将重建内存存储", path, f.EmbeddingModel, model)
+		return s, nil
+	}
+	s.dims = f.Dims
+	for _, e := range f.Entries {
+		s.put(e)
+	}
+	return s, nil
+}
+
+// reset 清空全部文本块，调用方需持有写锁
+func (s *memoryStore) reset() {
+	s.entries = map[string]*memoryEntry{}
+	s.df = map[string]int{}
+	s.total = 0
+	s.dims = 0
+}
+
+// put 写入文本块并更新 BM25 统计，调用方需持有写锁
+func (s *memoryStore) put(e *memoryEntry) {
+	s.remove(e.ID)
+
+	e.terms = map[string]int{}
+	for _, t := range tokenize(e.Content) {
+		e.terms[t]++
+		e.size++
+	}
+	for t := range e.terms {
+		s.df[t]++
+	}
+	s.total += e.size
+	s.entries[e.ID] = e
+}
+
+// remove 删除文本块并更新 BM25 统计，调用方需持有写锁
+func (s *memoryStore) remove(id string) {
+	old, ok := s.entries[id]
+	if !ok {
+		return
+	}
+	for t := range old.terms {
+		if s.df[t]--; s.df[t] == 0 {
+			delete(s.df, t)
+		}
+	}
+	s.total -= old.size
+	delete(s.entries, id)
+}
+
+// Store 实现 indexer.Indexer，向量化并写入文本块，相同 ID 覆盖
+func (s *memoryStore) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
+	options := indexer.GetCommonOptions(&indexer.Options{Embedding: s.embedder}, opts...)
+	if options.Embedding == nil {
+		return nil, fmt.Errorf("embedding 未配置")
+	}
+
+	entries := make([]*memoryEntry, 0, len(docs))
+	for start := 0; start < len(docs); start += s.batchSize {
+		batch := docs[start:min(start+s.batchSize, len(docs))]
+		texts := make([]string, len(batch))
+		for i, doc := range batch {
+			texts[i] = doc.Content
+		}
+		vectors, err := options.Embedding.EmbedStrings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("向量化失败: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("向量数量 %d 与文档数量 %d 不一致", len(vectors), len(batch))
+		}
+		for i, doc := range batch {
+			entries = append(entries, &memoryEntry{
+				ID:       doc.ID,
+				Content:  doc.Content,
+				MetaData: doc.MetaData,
+				Vector:   vectors[i],
+				Hash:     contentHash(doc),
+			})
+		}
+	}
+
+	s.mu.Lock()
+	dims := s.dims
+	if len(s.entries) == 0 && len(entries) > 0 {
+		dims = len(entries[0].Vector)
+	}
+	for _, e := range entries {
+		if len(e.Vector) != dims {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("文本块 %s 的向量维度为 %d，存储中为 %d，请删除 %s 后重建", e.ID, len(e.Vector), dims, s.path)
+		}
+	}
+	s.dims = dims
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		s.put(e)
+		ids = append(ids, e.ID)
+	}
+	s.mu.Unlock()
+
+	return ids, s.save()
+}
+
+// Delete 删除文本块
+func (s *memoryStore) Delete(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	for _, id := range ids {
+		s.remove(id)
+	}
+	s.mu.Unlock()
+	return s.save()
+}
+
+// Sync 增量同步文本块：只向量化新增或变化的文本块，并删除不再存在的文本块
+func (s *memoryStore) Sync(ctx context.Context, docs []*schema.Document) ([]string, error) {
+	if err := s.checkDims(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	manifest := &indexManifest{Chunks: make(map[string]string, len(s.entries))}
+	for id, e := range s.entries {
+		manifest.Chunks[id] = e.Hash
+	}
+	s.mu.RUnlock()
+
+	changed, stale, _ := manifest.diff(docs)
+	log.Printf("  - 新增或变更 %d 个，未变化 %d 个，已失效 %d 个", len(changed), len(docs)-len(changed), len(stale))
+
+	if err := s.Delete(stale); err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	return s.Store(ctx, changed)
+}
+
+// checkDims 探测 embedder 的向量维度，与已存储的向量不一致时清空存储以便重建
+func (s *memoryStore) checkDims(ctx context.Context) error {
+	s.mu.RLock()
+	stored := s.dims
+	s.mu.RUnlock()
+	if stored == 0 {
+		return nil
+	}
+
+	dims, err := embeddingDims(ctx, s.embedder)
+	if err != nil {
+		return err
+	}
+	if dims != stored {
+		log.Printf("  - 存储中的向量维度为 %d，embedder 为 %d，将重建内存存储", stored, dims)
+		s.mu.Lock()
+		s.reset()
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// save 持久化到文件
+func (s *memoryStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	f := memoryFile{EmbeddingModel: s.model, Dims: s.dims, Entries: make([]*memoryEntry, 0, len(s.entries))}
+	for _, e := range s.entries {
+		f.Entries = append(f.Entries, e)
+	}
+	s.mu.RUnlock()
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].ID < f.Entries[j].ID })
+
+	b, err := json.Marshal(&f)
+	if err != nil {
+		return fmt.Errorf("序列化内存存储失败: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("写入内存存储文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入内存存储文件失败: %w", err)
+	}
+	return nil
+}
+
+// memorySearchMode 内存检索方式
+type memorySearchMode int
+
+const (
+	memorySearchKNN memorySearchMode = iota
+	memorySearchBM25
+)
+
+// memoryRetriever 实现 retriever.Retriever，在内存存储上执行 kNN 或 BM25 检索
+type memoryRetriever struct {
+	store *memoryStore
+	mode  memorySearchMode
+	topK  int
+}
+
+// newMemoryRetriever 创建内存检索器
+func newMemoryRetriever(store *memoryStore, mode memorySearchMode, topK int) *memoryRetriever {
+	return &memoryRetriever{store: store, mode: mode, topK: topK}
+}
+
+// Retrieve 返回得分最高的 topK 个文本块，Document.Score 为余弦相似度或 BM25 分数
+func (r *memoryRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK := r.topK
+	options := retriever.GetCommonOptions(&retriever.Options{
+		TopK:      &topK,
+		Embedding: r.store.embedder,
+	}, opts...)
+
+	var scoreFn func(e *memoryEntry) float64
+	switch r.mode {
+	case memorySearchKNN:
+		if options.Embedding == nil {
+			return nil, fmt.Errorf("embedding 未配置")
+		}
+		vectors, err := options.Embedding.EmbedStrings(ctx, []string{query})
+		if err != nil {
+			return nil, fmt.Errorf("向量化查询失败: %w", err)
+		}
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("查询向量数量错误: %d", len(vectors))
+		}
+		r.store.mu.RLock()
+		dims := r.store.dims
+		r.store.mu.RUnlock()
+		if dims != 0 && len(vectors[0]) != dims {
+			return nil, fmt.Errorf("查询向量维度为 %d，存储中为 %d，请重新索引", len(vectors[0]), dims)
+		}
+		scoreFn = func(e *memoryEntry) float64 { return cosine(vectors[0], e.Vector) }
+	case memorySearchBM25:
+		scoreFn = r.store.bm25Scorer(tokenize(query))
+	default:
+		return nil, fmt.Errorf("未知的检索方式: %d", r.mode)
+	}
+
+	r.store.mu.RLock()
+	docs := make([]*schema.Document, 0, len(r.store.entries))
+	for _, e := range r.store.entries {
+		score := scoreFn(e)
+		if r.mode == memorySearchBM25 && score == 0 {
+			continue
+		}
+		if options.ScoreThreshold != nil && score < *options.ScoreThreshold {
+			continue
+		}
+		meta := make(map[string]any, len(e.MetaData))
+		for k, v := range e.MetaData {
+			meta[k] = v
+		}
+		docs = append(docs, (&schema.Document{ID: e.ID, Content: e.Content, MetaData: meta}).WithScore(score))
+	}
+	r.store.mu.RUnlock()
+
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Score() != docs[j].Score() {
+			return docs[i].Score() > docs[j].Score()
+		}
+		return docs[i].ID < docs[j].ID
+	})
+	if len(docs) > *options.TopK {
+		docs = docs[:*options.TopK]
+	}
+	return docs, nil
+}
+
+// bm25Scorer 返回查询词对文本块的 BM25 打分函数，语料统计在创建时读取
+func (s *memoryStore) bm25Scorer(queryTerms []string) func(e *memoryEntry) float64 {
+	s.mu.RLock()
+	n := float64(len(s.entries))
+	avgLen := 0.0
+	if n > 0 {
+		avgLen = float64(s.total) / n
+	}
+	idf := make(map[string]float64, len(queryTerms))
+	for _, t := range queryTerms {
+		df := float64(s.df[t])
+		idf[t] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+	s.mu.RUnlock()
+
+	return func(e *memoryEntry) float64 {
+		var score float64
+		for t, w := range idf {
+			tf := float64(e.terms[t])
+			if tf == 0 {
+				continue
+			}
+			score += w * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(e.size)/avgLen))
+		}
+		return score
+	}
+}
+
+// tokenize 分词：连续字符按二元组切分，单字片段保留为一元，与 ES cjk 分词器的行为接近
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		run    []rune
+	)
+	flush := func() {
+		switch {
+		case len(run) == 1:
+			tokens = append(tokens, string(run))
+		case len(run) > 1:
+			for i := 0; i+1 < len(run); i++ {
+				tokens = append(tokens, string(run[i:i+2]))
+			}
+		}
+		run = run[:0]
+	}
+	for _, r := range text {
+		if !isTermRune(r) {
+			flush()
+			continue
+		}
+		run = append(run, r)
+	}
+	flush()
+	return tokens
+}
+
+// cosine 余弦相似度，任一向量为零向量时返回 0
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
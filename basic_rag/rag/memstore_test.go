@@ -0,0 +1,134 @@
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+var memoryTestDocs = []*schema.Document{
+	{ID: "2.3.1", Content: "风寒束表证 风寒之邪外袭肌表，以恶寒重、发热轻、无汗为主症。"},
+	{ID: "2.3.2", Content: "风热犯表证 风热之邪侵犯肌表，以发热重、恶寒轻、咽痛为主症。"},
+	{ID: "2.5.1", Content: "肝郁气滞证 情志不遂，肝失疏泄，以胸胁胀痛、善太息为主症。"},
+}
+
+func newTestMemoryStore(t *testing.T, path string) *memoryStore {
+	t.Helper()
+	store, err := newMemoryStore(&fake.Embedder{Dims: 128}, "hash", path)
+	if err != nil {
+		t.Fatalf("newMemoryStore() error = %v", err)
+	}
+	return store
+}
+
+func TestMemoryRetriever(t *testing.T) {
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+	if _, err := store.Store(ctx, memoryTestDocs); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mode    memorySearchMode
+		query   string
+		wantTop string
+		wantN   int
+	}{
+		{name: "kNN 风寒", mode: memorySearchKNN, query: "恶寒重发热轻", wantTop: "2.3.1", wantN: 2},
+		{name: "kNN 肝郁", mode: memorySearchKNN, query: "胸胁胀痛善太息", wantTop: "2.5.1", wantN: 2},
+		{name: "BM25 风热", mode: memorySearchBM25, query: "咽痛", wantTop: "2.3.2", wantN: 1},
+		{name: "BM25 共有词", mode: memorySearchBM25, query: "风寒肌表", wantTop: "2.3.1", wantN: 2},
+		{name: "BM25 无命中", mode: memorySearchBM25, query: "头痛", wantN: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := newMemoryRetriever(store, tt.mode, 2).Retrieve(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Retrieve() error = %v", err)
+			}
+			if len(docs) != tt.wantN {
+				t.Fatalf("got %d docs, want %d", len(docs), tt.wantN)
+			}
+			if tt.wantN > 0 && docs[0].ID != tt.wantTop {
+				t.Errorf("top doc = %s, want %s", docs[0].ID, tt.wantTop)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSync(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "memory.json")
+	store := newTestMemoryStore(t, path)
+
+	ids, err := store.Sync(ctx, memoryTestDocs)
+	if err != nil || len(ids) != 3 {
+		t.Fatalf("首次同步 = %v, %v, want 3 个文本块", ids, err)
+	}
+
+	// 重新加载后未变化的文本块不再向量化，变化的重新写入，删除的被移除
+	store = newTestMemoryStore(t, path)
+	changed := []*schema.Document{
+		memoryTestDocs[0],
+		{ID: "2.3.2", Content: "风热犯表证 风热之邪侵犯肌表，以发热、咽喉肿痛为主症。"},
+	}
+	ids, err = store.Sync(ctx, changed)
+	if err != nil || len(ids) != 1 || ids[0] != "2.3.2" {
+		t.Fatalf("增量同步 = %v, %v, want [2.3.2]", ids, err)
+	}
+	if _, ok := store.entries["2.5.1"]; ok {
+		t.Errorf("已删除的文本块仍在存储中")
+	}
+
+	docs, err := newMemoryRetriever(store, memorySearchBM25, 3).Retrieve(ctx, "咽喉肿痛")
+	if err != nil || len(docs) != 1 || docs[0].ID != "2.3.2" {
+		t.Errorf("检索变更后的文本块 = %v, %v", docs, err)
+	}
+}
+
+func TestMemoryStoreEmbedderChange(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "memory.json")
+	if _, err := newTestMemoryStore(t, path).Sync(ctx, memoryTestDocs); err != nil {
+		t.Fatalf("首次同步 error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		embedder *fake.Embedder
+		model    string
+	}{
+		{name: "更换向量模型", embedder: &fake.Embedder{Dims: 128}, model: "other"},
+		{name: "向量维度变化", embedder: &fake.Embedder{Dims: 64}, model: "hash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := newMemoryStore(tt.embedder, tt.model, path)
+			if err != nil {
+				t.Fatalf("newMemoryStore() error = %v", err)
+			}
+			// 旧向量不再复用，全部重新向量化
+			ids, err := store.Sync(ctx, memoryTestDocs)
+			if err != nil || len(ids) != len(memoryTestDocs) {
+				t.Fatalf("Sync() = %v, %v, want 全部重建", ids, err)
+			}
+			docs, err := newMemoryRetriever(store, memorySearchKNN, 1).Retrieve(ctx, "胸胁胀痛善太息")
+			if err != nil || len(docs) != 1 || docs[0].ID != "2.5.1" {
+				t.Errorf("重建后检索 = %v, %v", docs, err)
+			}
+		})
+	}
+
+	// 查询向量与存储维度不一致时报错，而不是静默返回 0 分
+	store := newTestMemoryStore(t, path)
+	_, err := newMemoryRetriever(store, memorySearchKNN, 1).Retrieve(ctx, "咽痛", retriever.WithEmbedding(&fake.Embedder{Dims: 32}))
+	if err == nil {
+		t.Error("维度不一致时 Retrieve() error = nil")
+	}
+}
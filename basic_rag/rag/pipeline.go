@@ -0,0 +1,133 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+	"common/provider"
+)
+
+// Pipeline 组装好的 RAG 流程：文档已索引，可直接检索与生成回答
+type Pipeline struct {
+	ChatModel model.BaseChatModel
+
+	conf      *Config
+	retriever retriever.Retriever
+	reranker  reranker
+	close     func()
+}
+
+// NewPipeline 按 c 加载文档、建立索引并创建模型，c 需已通过校验
+func NewPipeline(ctx context.Context, c *Config) (p *Pipeline, err error) {
+	// 加载文档
+	docs, err := loadDocuments(ctx, c.RAG.DataPath)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("成功加载 %d 个文档", len(docs))
+
+	// 文档分块
+	chunkedDocs := chunkDocuments(docs)
+	if len(chunkedDocs) == 0 {
+		return nil, errs.New(errs.ErrNoDocuments, "文档分块")
+	}
+	log.Printf("成功分块，共 %d 个文本块", len(chunkedDocs))
+
+	//  创建 Embedder
+	embedder, closeEmbedder, err := newEmbedder(ctx, c)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrModelUnavailable, "创建 embedder", err)
+	}
+	defer func() {
+		if err != nil {
+			closeEmbedder()
+		}
+	}()
+	log.Printf("成功初始化 Embedding 模型: %s/%s", c.LLM.Provider, c.LLM.EmbeddingModel)
+
+	// 创建 LLM 模型
+	chatModel, err := provider.NewChatModel(ctx, &c.LLM)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrModelUnavailable, "创建 chat model", err)
+	}
+	log.Printf("成功初始化 LLM 模型: %s/%s", c.LLM.Provider, c.LLM.ChatModel)
+
+	// 连接向量存储
+	backend, err := newVectorBackend(c, embedder)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrRetrieval, "连接向量存储", err)
+	}
+
+	//  创建索引并存储文档
+	log.Printf("步骤 5: 创建索引并存储文档到 %s...", c.RAG.Store)
+	ids, err := backend.Index(ctx, chunkedDocs)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrRetrieval, "索引文档", err)
+	}
+	log.Printf("成功索引 %d 个文档块", len(ids))
+
+	// 混合检索器：多召回一些，交给重排筛选
+	ret, err := backend.Retriever(ctx, &fusionRetrieverConfig{
+		TopK:          c.RAG.RetrieveTopK,
+		CandidateK:    2 * c.RAG.RetrieveTopK,
+		fusionOptions: c.RAG.fusionOptions(),
+	})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrRetrieval, "创建混合检索器", err)
+	}
+
+	// 重排器
+	rr, err := newReranker(&c.Rerank, chatModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pipeline{ChatModel: chatModel, conf: c, retriever: ret, reranker: rr, close: closeEmbedder}, nil
+}
+
+// Search 混合检索后重排，返回进入提示词的文档
+func (p *Pipeline) Search(ctx context.Context, query string) ([]*schema.Document, error) {
+	return retrieveContext(ctx, p.retriever, p.reranker, query, p.conf.RAG.ContextTopK)
+}
+
+// Answer 检索并流式生成单轮回答到 w，末尾输出参考条文，开启依据校验时结果中带有支持率与缺乏依据的论断
+func (p *Pipeline) Answer(ctx context.Context, question string, w io.Writer) (*Answer, error) {
+	docs, err := p.Search(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errs.New(errs.ErrNoDocuments, "检索 "+question)
+	}
+
+	// 对话模版
+	messages, err := buildChatMessages(ctx, docs, question, nil)
+	if err != nil {
+		return nil, fmt.Errorf("构建提示词消息失败: %w", err)
+	}
+
+	// 对话输出
+	answer, err := chatWithGrounding(ctx, p.ChatModel, &p.conf.Grounding, messages, docs, w)
+	if err != nil {
+		return nil, err
+	}
+	printReferences(w, extractCitations(answer.Text, docs))
+	return answer, nil
+}
+
+// NewSession 创建基于该流程的多轮对话
+func (p *Pipeline) NewSession() *Session {
+	return newSession(p.ChatModel, p.Search, p.conf)
+}
+
+// Close 释放向量缓存等资源
+func (p *Pipeline) Close() {
+	p.close()
+}
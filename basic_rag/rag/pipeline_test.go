@@ -0,0 +1,97 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+
+	"common/config"
+	"common/fake"
+	"common/provider"
+)
+
+// newTestPipeline 用假模型与内存存储创建 Pipeline，不依赖 Elasticsearch 与在线模型
+func newTestPipeline(t *testing.T, cm *fake.ChatModel, grounding GroundingConfig) *Pipeline {
+	t.Helper()
+	provider.Register("fake-rag",
+		config.Provider{BaseURL: "http://fake", ChatModel: "fake", EmbeddingModel: "hash", Timeout: time.Second},
+		func(context.Context, *config.LLM) (model.ToolCallingChatModel, error) { return cm, nil },
+		func(context.Context, *config.LLM) (embedding.Embedder, error) { return &fake.Embedder{}, nil },
+	)
+
+	c := DefaultConfig()
+	c.LLM.Provider = "fake-rag"
+	c.RAG.DataPath = "../../data/tcm.txt"
+	c.RAG.Store = "memory"
+	c.RAG.MemoryPath = ""
+	c.Cache.Path = ""
+	c.Grounding = grounding
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	p, err := NewPipeline(context.Background(), c)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestPipelineAnswer(t *testing.T) {
+	cm := fake.NewChatModel(fake.Reply("风寒感冒以恶寒重、发热轻为主。"))
+	p := newTestPipeline(t, cm, GroundingConfig{})
+
+	var out strings.Builder
+	answer, err := p.Answer(context.Background(), "风寒感冒 症状", &out)
+	if err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+	if answer.Text != "风寒感冒以恶寒重、发热轻为主。" || !strings.Contains(out.String(), answer.Text) {
+		t.Errorf("answer = %q, output = %q", answer.Text, out.String())
+	}
+	if answer.Grounding != nil {
+		t.Errorf("未开启校验时 Grounding = %+v, want nil", answer.Grounding)
+	}
+	// 提示词中应带有检索到的条文
+	input := cm.LastInput()
+	if len(input) == 0 || !strings.Contains(input[len(input)-1].Content, "风寒") {
+		t.Errorf("model input = %v", input)
+	}
+}
+
+func TestPipelineNewSession(t *testing.T) {
+	cm := fake.NewChatModel(fake.Reply("宜辛温解表。"))
+	p := newTestPipeline(t, cm, GroundingConfig{})
+
+	session := p.NewSession()
+	if _, err := session.Ask(context.Background(), "风寒感冒怎么治疗？", &strings.Builder{}); err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	if len(session.history) != 2 {
+		t.Errorf("history has %d messages, want 2", len(session.history))
+	}
+}
+
+func TestPipelineAnswerGrounding(t *testing.T) {
+	cm := fake.NewChatModel(fake.Reply("风寒束表证以恶寒重、发热轻为主症。患者宜多饮热水并卧床休息。"))
+	p := newTestPipeline(t, cm, GroundingConfig{Mode: "lexical", Threshold: 0.8, LexicalThreshold: 0.5})
+
+	answer, err := p.Answer(context.Background(), "风寒感冒 症状", &strings.Builder{})
+	if err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+	if answer.Grounding == nil {
+		t.Fatal("Grounding = nil, want result")
+	}
+	if answer.Grounding.Score != 0.5 || len(answer.Grounding.Claims) != 2 {
+		t.Errorf("Grounding = %+v, want score 0.5 over 2 claims", answer.Grounding)
+	}
+	if got := answer.Grounding.Unsupported(); len(got) != 1 || got[0] != "患者宜多饮热水并卧床休息" {
+		t.Errorf("Unsupported() = %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+)
+
+// createTemplate 创建对话模板，要求模型按条文编号注明依据
+func createTemplate() prompt.ChatTemplate {
+	// 创建模板，使用 FString 格式
+	return prompt.FromMessages(schema.FString,
+		// 系统消息模板
+		schema.SystemMessage("你是专业的老中医,专注于用户问题回答,不要回答医学以外问题。"+
+			"回答须基于获取的文档,每个结论后用方括号注明依据的条文编号,如 [2.5.1],只能引用文档中给出的编号"),
+
+		// 插入需要的对话历史（新对话的话这里不填）
+		schema.MessagesPlaceholder("chat_history", true),
+
+		// 用户消息模板
+		schema.UserMessage(`获取的文档: 
+		{context}
+
+		用户问题:
+		{question}`),
+	)
+}
+
+// buildChatContext 构建聊天上下文，每个文本块前标注引用编号
+func buildChatContext(docs []*schema.Document) (content string) {
+	for _, doc := range docs {
+		content += "[" + citationLabel(doc) + "]\n" + doc.Content + "\n\n"
+	}
+	return content
+}
+
+// buildChatMessages 构建提示词消息，history 为此前的对话轮次，可为空
+func buildChatMessages(ctx context.Context, docs []*schema.Document, query string, history []*schema.Message) ([]*schema.Message, error) {
+	buildChatContext := buildChatContext(docs)
+	template := createTemplate()
+	messages, err := template.Format(ctx, map[string]any{
+		"context":      buildChatContext,
+		"question":     query,
+		"chat_history": history,
+	})
+	return messages, err
+}
+
+// chat 流式输出模型回答到 w，并返回完整回答
+func chat(ctx context.Context, chatModel model.BaseChatModel, messages []*schema.Message, w io.Writer) (string, error) {
+	streamMsgs, err := chatModel.Stream(ctx, messages)
+	if err != nil {
+		return "", errs.Wrap(errs.ErrModelUnavailable, "生成回答", err)
+	}
+	defer streamMsgs.Close()
+
+	var answer strings.Builder
+	for {
+		msg, err := streamMsgs.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return answer.String(), errs.Wrap(errs.ErrModelUnavailable, "接收回答", err)
+		}
+		fmt.Fprint(w, msg.Content)
+		answer.WriteString(msg.Content)
+	}
+	return answer.String(), nil
+}
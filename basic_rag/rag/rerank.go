@@ -0,0 +1,263 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// metaRerankScore 重排分数，混合检索分数仍保留在 Document.Score 中
+const metaRerankScore = "rerank_score"
+
+// reranker 对检索结果重新打分，返回的分数与 docs 一一对应，越大越相关
+type reranker interface {
+	Score(ctx context.Context, query string, docs []*schema.Document) ([]float64, error)
+}
+
+// rerankDocuments 重排并截取前 topN 个文档，重排分数写入元数据
+func rerankDocuments(ctx context.Context, r reranker, query string, docs []*schema.Document, topN int) ([]*schema.Document, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	scores, err := r.Score(ctx, query, docs)
+	if err != nil {
+		return nil, fmt.Errorf("重排失败: %w", err)
+	}
+	if len(scores) != len(docs) {
+		return nil, fmt.Errorf("重排失败: 分数数量 %d 与文档数量 %d 不一致", len(scores), len(docs))
+	}
+
+	ranked := make([]*schema.Document, len(docs))
+	for i, doc := range docs {
+		if doc.MetaData == nil {
+			doc.MetaData = map[string]any{}
+		}
+		doc.MetaData[metaRerankScore] = scores[i]
+		ranked[i] = doc
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].MetaData[metaRerankScore].(float64) > ranked[j].MetaData[metaRerankScore].(float64)
+	})
+
+	if topN > 0 && len(ranked) > topN {
+		ranked = ranked[:topN]
+	}
+	return ranked, nil
+}
+
+// newReranker 按 rerank.type 创建重排器：http、llm、lexical
+func newReranker(c *RerankConfig, chatModel model.BaseChatModel) (reranker, error) {
+	switch c.Type {
+	case "http":
+		return &httpReranker{URL: c.URL, APIKey: c.APIKey, Model: c.Model}, nil
+	case "llm":
+		if chatModel == nil {
+			return nil, fmt.Errorf("llm 重排需要 chat model")
+		}
+		return &llmReranker{ChatModel: chatModel}, nil
+	case "lexical", "":
+		return &lexicalReranker{}, nil
+	default:
+		return nil, fmt.Errorf("未知的重排器: %s", c.Type)
+	}
+}
+
+// httpReranker 调用 cross-encoder 重排服务，请求与响应格式兼容 Cohere / Jina / TEI 的 rerank 接口
+type httpReranker struct {
+	URL     string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+}
+
+type httpRerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type httpRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *httpReranker) Score(ctx context.Context, query string, docs []*schema.Document) ([]float64, error) {
+	if r.URL == "" {
+		return nil, fmt.Errorf("未配置重排服务地址")
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	body, err := json.Marshal(httpRerankRequest{Model: r.Model, Query: query, Documents: texts, TopN: len(texts)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求重排服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("重排服务返回错误: %s %s", resp.Status, b)
+	}
+
+	var out httpRerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("解析重排结果失败: %w", err)
+	}
+
+	scores := make([]float64, len(docs))
+	for _, res := range out.Results {
+		if res.Index < 0 || res.Index >= len(scores) {
+			return nil, fmt.Errorf("重排结果下标越界: %d", res.Index)
+		}
+		scores[res.Index] = res.RelevanceScore
+	}
+	return scores, nil
+}
+
+// llmReranker 让大模型为每个文档打 0-10 分
+type llmReranker struct {
+	ChatModel model.BaseChatModel
+}
+
+// decodeJSONArray 解析模型输出中第一个能解码为 []T 的 JSON 数组，兼容 ```json 代码块
+// 从每个 [ 处依次尝试，说明文字中的 [1]、[太阳病] 等方括号会被跳过
+func decodeJSONArray[T any](content string) ([]T, error) {
+	var lastErr error
+	for i := strings.IndexByte(content, '['); i >= 0; {
+		var items []T
+		err := json.NewDecoder(strings.NewReader(content[i:])).Decode(&items)
+		if err == nil {
+			return items, nil
+		}
+		lastErr = err
+
+		next := strings.IndexByte(content[i+1:], '[')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if lastErr == nil {
+		return nil, fmt.Errorf("输出中没有 JSON 数组: %s", content)
+	}
+	return nil, fmt.Errorf("输出中没有可解析的 JSON 数组: %w", lastErr)
+}
+
+func (r *llmReranker) Score(ctx context.Context, query string, docs []*schema.Document) ([]float64, error) {
+	var b strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&b, "[%d] %s\n\n", i, doc.Content)
+	}
+
+	messages := []*schema.Message{
+		schema.SystemMessage("你是检索结果相关性评审。根据用户问题，为每个文档给出 0-10 的相关性分数，10 表示完全回答了问题。" +
+			"只输出 JSON 数组，例如 [{\"index\":0,\"score\":7}]，不要输出其它内容。"),
+		schema.UserMessage(fmt.Sprintf("用户问题：%s\n\n文档：\n%s", query, b.String())),
+	}
+	resp, err := r.ChatModel.Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("模型打分失败: %w", err)
+	}
+
+	type llmScore struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	items, err := decodeJSONArray[llmScore](resp.Content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模型打分结果失败: %w", err)
+	}
+
+	// 漏打或重复打分时不能按 0 分处理，交由调用方退回混合检索排序
+	scores := make([]float64, len(docs))
+	scored := make([]bool, len(docs))
+	for _, item := range items {
+		if item.Index < 0 || item.Index >= len(scores) || scored[item.Index] {
+			return nil, fmt.Errorf("模型打分结果下标无效: %d", item.Index)
+		}
+		scores[item.Index] = item.Score / 10
+		scored[item.Index] = true
+	}
+	if len(items) != len(docs) {
+		return nil, fmt.Errorf("模型打分数量 %d 与文档数量 %d 不一致", len(items), len(docs))
+	}
+	return scores, nil
+}
+
+// lexicalReranker 本地字面重排：查询的字符二元组在文档中的覆盖率，无需调用外部服务
+type lexicalReranker struct{}
+
+func (r *lexicalReranker) Score(_ context.Context, query string, docs []*schema.Document) ([]float64, error) {
+	queryGrams := bigrams(query)
+	scores := make([]float64, len(docs))
+	if len(queryGrams) == 0 {
+		return scores, nil
+	}
+
+	for i, doc := range docs {
+		docGrams := bigrams(doc.Content)
+		hit := 0
+		for g := range queryGrams {
+			if _, ok := docGrams[g]; ok {
+				hit++
+			}
+		}
+		scores[i] = float64(hit) / float64(len(queryGrams))
+	}
+	return scores, nil
+}
+
+// bigrams 提取文本的字符二元组，忽略空白与标点
+func bigrams(text string) map[string]struct{} {
+	grams := make(map[string]struct{})
+	var prev rune
+	for _, r := range text {
+		if !isTermRune(r) {
+			prev = 0
+			continue
+		}
+		if prev != 0 {
+			grams[string([]rune{prev, r})] = struct{}{}
+		}
+		prev = r
+	}
+	return grams
+}
+
+// isTermRune 判断是否为参与匹配的字符
+func isTermRune(r rune) bool {
+	return !strings.ContainsRune(" \t\r\n，。、；：？！,.;:?!（）()【】[]“”\"'-", r)
+}
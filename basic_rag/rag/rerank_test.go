@@ -0,0 +1,175 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+// newRerankTestDocs 构造带混合检索分数的文档，每个用例使用新的副本
+func newRerankTestDocs() []*schema.Document {
+	docs := []*schema.Document{
+		{ID: "2.5.1", Content: "肝郁气滞证 情志不遂，以胸胁胀痛为主症。"},
+		{ID: "2.3.2", Content: "风热犯表证 以发热重、恶寒轻、咽痛为主症。"},
+		{ID: "2.3.1", Content: "风寒束表证 以恶寒重、发热轻、无汗为主症。"},
+	}
+	for i, doc := range docs {
+		doc.WithScore(float64(3 - i))
+	}
+	return docs
+}
+
+// stubReranker 返回固定分数
+type stubReranker []float64
+
+func (s stubReranker) Score(context.Context, string, []*schema.Document) ([]float64, error) {
+	return s, nil
+}
+
+func TestRerankDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       reranker
+		topN    int
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "字面重排", r: &lexicalReranker{}, topN: 3, wantIDs: []string{"2.3.1", "2.3.2", "2.5.1"}},
+		{name: "截取前 topN 个", r: &lexicalReranker{}, topN: 1, wantIDs: []string{"2.3.1"}},
+		{name: "topN 为 0 不截取", r: stubReranker{0.1, 0.3, 0.2}, wantIDs: []string{"2.3.2", "2.3.1", "2.5.1"}},
+		{name: "同分保持原顺序", r: stubReranker{0.5, 0.5, 0.5}, topN: 2, wantIDs: []string{"2.5.1", "2.3.2"}},
+		{name: "分数数量不一致", r: stubReranker{0.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := newRerankTestDocs()
+			hybrid := map[string]float64{}
+			for _, doc := range docs {
+				hybrid[doc.ID] = doc.Score()
+			}
+
+			ranked, err := rerankDocuments(context.Background(), tt.r, "恶寒重发热轻", docs, tt.topN)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("rerankDocuments() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rerankDocuments() error = %v", err)
+			}
+
+			var ids []string
+			for i, doc := range ranked {
+				ids = append(ids, doc.ID)
+				score, ok := doc.MetaData[metaRerankScore].(float64)
+				if !ok {
+					t.Fatalf("%s 缺少 %s", doc.ID, metaRerankScore)
+				}
+				if i > 0 && score > ranked[i-1].MetaData[metaRerankScore].(float64) {
+					t.Errorf("重排分数未按降序排列: %v", ids)
+				}
+				if doc.Score() != hybrid[doc.ID] {
+					t.Errorf("%s 混合分数 = %v, want %v", doc.ID, doc.Score(), hybrid[doc.ID])
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestHTTPReranker(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "按下标回填分数", status: http.StatusOK, body: `{"results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`, want: []float64{0.2, 0, 0.9}},
+		{name: "下标越界", status: http.StatusOK, body: `{"results":[{"index":3,"relevance_score":0.9}]}`, wantErr: true},
+		{name: "负数下标", status: http.StatusOK, body: `{"results":[{"index":-1,"relevance_score":0.9}]}`, wantErr: true},
+		{name: "服务返回错误", status: http.StatusUnauthorized, body: `{"message":"invalid api key"}`, wantErr: true},
+		{name: "响应不是 JSON", status: http.StatusOK, body: `rerank`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got httpRerankRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+					t.Errorf("Authorization = %q", auth)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			r := &httpReranker{URL: srv.URL, APIKey: "secret", Model: "bge-reranker"}
+			scores, err := r.Score(context.Background(), "恶寒重", newRerankTestDocs())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Score() = %v, want error", scores)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if !reflect.DeepEqual(scores, tt.want) {
+				t.Errorf("Score() = %v, want %v", scores, tt.want)
+			}
+			if got.Model != "bge-reranker" || got.Query != "恶寒重" || len(got.Documents) != 3 || got.TopN != 3 {
+				t.Errorf("request = %+v", got)
+			}
+		})
+	}
+}
+
+func TestLLMReranker(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "纯 JSON", reply: `[{"index":0,"score":2},{"index":1,"score":5},{"index":2,"score":10}]`, want: []float64{0.2, 0.5, 1}},
+		{name: "代码块", reply: "```json\n[{\"index\":2,\"score\":8},{\"index\":0,\"score\":1},{\"index\":1,\"score\":4}]\n```", want: []float64{0.1, 0.4, 0.8}},
+		{name: "前后带方括号说明", reply: `文档 [2] 最相关，[太阳病] 无关：[{"index":0,"score":0},{"index":1,"score":3},{"index":2,"score":9}] 以上见 [1]。`, want: []float64{0, 0.3, 0.9}},
+		{name: "没有 JSON 数组", reply: "文档 2 最相关", wantErr: true},
+		{name: "JSON 不完整", reply: `[{"index":0,"score":2},{"index":1`, wantErr: true},
+		{name: "漏打分", reply: `[{"index":0,"score":2},{"index":2,"score":9}]`, wantErr: true},
+		{name: "多打分", reply: `[{"index":0,"score":2},{"index":1,"score":3},{"index":2,"score":9},{"index":3,"score":1}]`, wantErr: true},
+		{name: "重复下标", reply: `[{"index":0,"score":2},{"index":0,"score":3},{"index":2,"score":9}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &llmReranker{ChatModel: fake.NewChatModel(fake.Reply(tt.reply))}
+			scores, err := r.Score(context.Background(), "恶寒重", newRerankTestDocs())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Score() = %v, want error", scores)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if !reflect.DeepEqual(scores, tt.want) {
+				t.Errorf("Score() = %v, want %v", scores, tt.want)
+			}
+		})
+	}
+}
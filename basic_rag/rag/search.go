@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+)
+
+// retrieveContext 混合检索后重排，返回进入提示词的前 topK 个文档
+func retrieveContext(ctx context.Context, ret retriever.Retriever, rr reranker, query string, topK int) ([]*schema.Document, error) {
+	//  演示混合搜索（向量检索 + BM25）
+	doc, err := demonstrateHybridSearch(ctx, ret, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重排后截取最终上下文
+	reranked, err := rerankDocuments(ctx, rr, query, doc, topK)
+	if err != nil {
+		log.Printf("重排失败，使用混合检索排序: %v", err)
+		return doc[:min(len(doc), topK)], nil
+	}
+	for j, d := range reranked {
+		log.Printf("    %d. 重排分数: %.4f, 混合分数: %.4f, ID: %s", j+1, d.MetaData[metaRerankScore], d.Score(), d.ID)
+	}
+	return reranked, nil
+}
+
+// demonstrateHybridSearch 演示混合搜索(向量检索 + BM25)
+// ES 内置的 RRF 需要企业许可证,这里分别执行两路检索并在应用侧用 RRF 融合
+func demonstrateHybridSearch(ctx context.Context, ret retriever.Retriever, query string) ([]*schema.Document, error) {
+	// 执行检索
+	docs, err := ret.Retrieve(ctx, query)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrRetrieval, "混合检索", err)
+	}
+
+	// 显示结果
+	log.Printf("  找到 %d 个相关文档:", len(docs))
+	for j, doc := range docs {
+		content := doc.Content
+		if len(content) > 100 {
+			content = content[:100] + "..."
+		}
+		content = strings.ReplaceAll(content, "\n", " ")
+		log.Printf("    %d. 融合分数: %.4f (BM25 排名 %v, 向量排名 %v), 内容: %s", j+1, doc.Score(), doc.MetaData[metaBM25Rank], doc.MetaData[metaVectorRank], content)
+	}
+
+	return docs, nil
+}
+
+// loadDocuments 加载 path 下的文档
+func loadDocuments(ctx context.Context, path string) ([]*schema.Document, error) {
+	loader, err := file.NewFileLoader(ctx, &file.FileLoaderConfig{
+		UseNameAsID: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建文件加载器失败: %w", err)
+	}
+
+	docs, err := loader.Load(ctx, document.Source{
+		URI: path,
+	})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrNoDocuments, "加载 "+path, err)
+	}
+
+	if len(docs) == 0 {
+		return nil, errs.New(errs.ErrNoDocuments, "加载 "+path)
+	}
+
+	return docs, nil
+}
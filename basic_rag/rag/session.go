@@ -0,0 +1,113 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+	"common/resilience"
+)
+
+// searchFunc 根据查询检索上下文文档
+type searchFunc func(ctx context.Context, query string) ([]*schema.Document, error)
+
+// Session 多轮 RAG 对话，保存对话历史，由 Pipeline.NewSession 创建
+type Session struct {
+	chatModel model.BaseChatModel
+	search    searchFunc
+	conf      *Config
+	history   []*schema.Message
+}
+
+// newSession 创建多轮对话，历史预算与依据校验取自 c
+func newSession(chatModel model.BaseChatModel, search searchFunc, c *Config) *Session {
+	return &Session{chatModel: chatModel, search: search, conf: c}
+}
+
+// Ask 回答一轮问题，回答流式写入 w，开启依据校验时结果中带有支持率与缺乏依据的论断
+func (s *Session) Ask(ctx context.Context, question string, w io.Writer) (*Answer, error) {
+	// 追问先改写成独立问题再检索，例如 "那怎么治疗？" -> "风寒感冒怎么治疗？"
+	query, err := s.condense(ctx, question)
+	if err != nil {
+		log.Printf("改写问题失败，使用原问题检索: %v", err)
+		query = question
+	}
+	if query != question {
+		log.Printf("  - 改写后的检索问题: %s", query)
+	}
+
+	docs, err := s.search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errs.New(errs.ErrNoDocuments, "检索 "+query)
+	}
+
+	history := truncateHistory(s.history, s.conf.RAG.HistoryTokenBudget)
+	messages, err := buildChatMessages(ctx, docs, question, history)
+	if err != nil {
+		return nil, fmt.Errorf("构建提示词消息失败: %w", err)
+	}
+
+	answer, err := chatWithGrounding(ctx, s.chatModel, &s.conf.Grounding, messages, docs, w)
+	if err != nil {
+		return nil, err
+	}
+	printReferences(w, extractCitations(answer.Text, docs))
+	s.history = append(s.history, schema.UserMessage(question), schema.AssistantMessage(answer.Text, nil))
+	return answer, nil
+}
+
+// Reset 清空对话历史
+func (s *Session) Reset() {
+	s.history = nil
+}
+
+// condense 结合对话历史把追问改写为独立问题，没有历史时原样返回
+func (s *Session) condense(ctx context.Context, question string) (string, error) {
+	if len(s.history) == 0 {
+		return question, nil
+	}
+
+	var b strings.Builder
+	for _, msg := range truncateHistory(s.history, s.conf.RAG.HistoryTokenBudget) {
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+	}
+
+	resp, err := s.chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("根据对话历史，把用户的最新问题改写为一个无需上下文也能理解的独立问题，用于知识库检索。只输出改写后的问题。"),
+		schema.UserMessage(fmt.Sprintf("对话历史：\n%s\n最新问题：%s", b.String(), question)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query := strings.TrimSpace(resp.Content)
+	if query == "" {
+		return question, nil
+	}
+	return query, nil
+}
+
+// truncateHistory 从最近一轮往前保留对话，总 token 不超过 budget，且以用户消息开头
+func truncateHistory(history []*schema.Message, budget int) []*schema.Message {
+	start, used := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		used += resilience.EstimateTokens(history[i].Content)
+		if used > budget {
+			break
+		}
+		start = i
+	}
+	for start < len(history) && history[start].Role != schema.User {
+		start++
+	}
+	return history[start:]
+}
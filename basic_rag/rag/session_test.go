@@ -0,0 +1,110 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+	"common/fake"
+)
+
+func TestTruncateHistory(t *testing.T) {
+	history := []*schema.Message{
+		schema.UserMessage("风寒感冒"),             // 4
+		schema.AssistantMessage("恶寒重发热轻", nil), // 6
+		schema.UserMessage("怎么治疗"),             // 4
+		schema.AssistantMessage("辛温解表", nil),   // 4
+	}
+
+	tests := []struct {
+		name   string
+		budget int
+		want   int
+	}{
+		{name: "预算充足", budget: 100, want: 4},
+		{name: "保留最近一轮", budget: 8, want: 2},
+		{name: "截断后以用户消息开头", budget: 14, want: 2},
+		{name: "预算不足一条", budget: 3, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateHistory(history, tt.budget)
+			if len(got) != tt.want {
+				t.Fatalf("got %d messages, want %d", len(got), tt.want)
+			}
+			if len(got) > 0 && got[0].Role != schema.User {
+				t.Errorf("first message role = %s, want user", got[0].Role)
+			}
+		})
+	}
+}
+
+func TestSessionAsk(t *testing.T) {
+	c := DefaultConfig()
+	c.Grounding = GroundingConfig{}
+
+	docs := []*schema.Document{{ID: "2.3.1", Content: "风寒束表证", MetaData: map[string]any{metaClauseID: "2.3.1"}}}
+	var queries []string
+	search := func(_ context.Context, query string) ([]*schema.Document, error) {
+		queries = append(queries, query)
+		return docs, nil
+	}
+	cm := fake.NewChatModel(
+		fake.Reply("风寒感冒以恶寒重为主[2.3.1]。"),
+		fake.Reply("风寒感冒怎么治疗？"), // 改写追问
+		fake.Reply("宜辛温解表[2.3.1]。"),
+	)
+	session := newSession(cm, search, c)
+
+	ctx := context.Background()
+	if _, err := session.Ask(ctx, "风寒感冒有什么表现？", io.Discard); err != nil {
+		t.Fatalf("first Ask() error = %v", err)
+	}
+	answer, err := session.Ask(ctx, "那怎么治疗？", io.Discard)
+	if err != nil {
+		t.Fatalf("second Ask() error = %v", err)
+	}
+
+	if answer.Text != "宜辛温解表[2.3.1]。" {
+		t.Errorf("answer = %q", answer.Text)
+	}
+	wantQueries := []string{"风寒感冒有什么表现？", "风寒感冒怎么治疗？"}
+	if len(queries) != 2 || queries[0] != wantQueries[0] || queries[1] != wantQueries[1] {
+		t.Errorf("search queries = %q, want %q", queries, wantQueries)
+	}
+	if len(session.history) != 4 {
+		t.Errorf("history has %d messages, want 4", len(session.history))
+	}
+
+	// 第一轮没有历史不改写，第二轮先改写再流式回答
+	calls := cm.Calls()
+	if len(calls) != 3 || calls[0].Stream != true || calls[1].Stream != false || calls[2].Stream != true {
+		t.Fatalf("unexpected model calls: %+v", calls)
+	}
+
+	session.Reset()
+	if len(session.history) != 0 {
+		t.Errorf("Reset() did not clear history")
+	}
+}
+
+func TestSessionAskNoDocuments(t *testing.T) {
+	search := func(context.Context, string) ([]*schema.Document, error) { return nil, nil }
+	cm := fake.NewChatModel()
+	session := newSession(cm, search, DefaultConfig())
+
+	_, err := session.Ask(context.Background(), "风寒感冒有什么表现？", io.Discard)
+	if !errors.Is(err, errs.ErrNoDocuments) {
+		t.Fatalf("Ask() error = %v, want ErrNoDocuments", err)
+	}
+	if len(cm.Calls()) != 0 {
+		t.Errorf("没有检索到文档时不应调用模型")
+	}
+	if len(session.history) != 0 {
+		t.Errorf("没有回答的问题不应写入历史")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"basic_rag/rag"
+)
+
+// runREPL 交互式多轮问答，输入 /reset 清空历史，exit 退出
+func runREPL(ctx context.Context, session *rag.Session, in io.Reader, out io.Writer) {
+	fmt.Fprintln(out, "进入多轮问答模式，输入 /reset 清空对话历史，输入 exit 退出")
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "\n> ")
+		if !scanner.Scan() {
+			return
+		}
+
+		question := strings.TrimSpace(scanner.Text())
+		switch question {
+		case "":
+			continue
+		case "exit", "quit":
+			return
+		case "/reset":
+			session.Reset()
+			fmt.Fprintln(out, "对话历史已清空")
+			continue
+		}
+
+		if _, err := session.Ask(ctx, question, out); err != nil {
+			log.Printf("回答失败: %v", err)
+		}
+		fmt.Fprintln(out)
+	}
+}
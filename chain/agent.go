@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+)
+
+// agentState 一次运行中交给模型的全部消息
+type agentState struct {
+	Messages []*schema.Message
+}
+
+// newToolAgent 构建 chat model → 分支 → tools → chat model 的 Chain
+// 模型先根据问题决定是否调用工具：请求工具时执行工具并把结果连同对话交回模型作答，否则直接输出回答
+func newToolAgent(ctx context.Context, cm model.ToolCallingChatModel, tools []tool.BaseTool) (compose.Runnable[[]*schema.Message, *schema.Message], error) {
+	toolInfos := make([]*schema.ToolInfo, 0, len(tools))
+	for _, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, errs.Wrap(errs.ErrToolCall, "获取工具信息", err)
+		}
+		toolInfos = append(toolInfos, info)
+	}
+	chatModel, err := cm.WithTools(toolInfos)
+	if err != nil {
+		return nil, fmt.Errorf("绑定工具失败: %w", err)
+	}
+	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: tools})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "创建工具节点", err)
+	}
+
+	// 工具分支：记录模型发起的 tool_calls → 执行工具 → 拼接完整对话 → 模型作答
+	// 子 Chain 没有自己的状态，通过 ProcessState 读写外层 Chain 的状态
+	toolsChain := compose.NewChain[*schema.Message, *schema.Message]()
+	toolsChain.
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, msg *schema.Message) (*schema.Message, error) {
+			err := compose.ProcessState(ctx, func(_ context.Context, st *agentState) error {
+				st.Messages = append(st.Messages, msg)
+				return nil
+			})
+			return msg, err
+		}), compose.WithNodeName("record_tool_calls")).
+		AppendToolsNode(toolsNode, compose.WithNodeName("search")).
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, results []*schema.Message) (messages []*schema.Message, err error) {
+			err = compose.ProcessState(ctx, func(_ context.Context, st *agentState) error {
+				st.Messages = append(st.Messages, results...)
+				messages = st.Messages
+				return nil
+			})
+			return messages, err
+		}), compose.WithNodeName("with_tool_results")).
+		AppendChatModel(chatModel, compose.WithNodeName("answer_model"))
+
+	branch := compose.NewStreamChainBranch(func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (string, error) {
+		isToolCall, err := hasToolCalls(sr)
+		if err != nil {
+			return "", err
+		}
+		if isToolCall {
+			return "tools", nil
+		}
+		return "answer", nil
+	})
+	branch.AddGraph("tools", toolsChain).AddPassthrough("answer")
+
+	chain := compose.NewChain[[]*schema.Message, *schema.Message](compose.WithGenLocalState(func(context.Context) *agentState {
+		return &agentState{}
+	}))
+	chain.
+		AppendChatModel(chatModel, compose.WithNodeName("chat_model"), compose.WithStatePreHandler(func(_ context.Context, in []*schema.Message, st *agentState) ([]*schema.Message, error) {
+			st.Messages = append(st.Messages, in...)
+			return in, nil
+		})).
+		AppendBranch(branch)
+
+	agent, err := chain.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译 Chain 失败: %w", err)
+	}
+	return agent, nil
+}
+
+// hasToolCalls 跳过开头的空分片，按第一个非空分片是否包含 tool_calls 判断
+func hasToolCalls(sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	defer sr.Close()
+	for {
+		msg, err := sr.Recv()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(msg.ToolCalls) > 0 {
+			return true, nil
+		}
+		if msg.Content != "" {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+// searchStub 记录调用参数并返回固定结果的搜索工具
+type searchStub struct {
+	args []string
+}
+
+func (s *searchStub) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "duckduckgo_text_search", Desc: "搜索网页"}, nil
+}
+
+func (s *searchStub) InvokableRun(_ context.Context, args string, _ ...tool.Option) (string, error) {
+	s.args = append(s.args, args)
+	return `{"results":[{"title":"北京天气","summary":"晴，15-25℃"}]}`, nil
+}
+
+// streamAnswer 流式运行 agent，返回拼接后的回答与分片数
+func streamAnswer(t *testing.T, cm *fake.ChatModel, search *searchStub, question string) (string, int) {
+	t.Helper()
+	agent, err := newToolAgent(context.Background(), cm, []tool.BaseTool{search})
+	if err != nil {
+		t.Fatalf("newToolAgent() error = %v", err)
+	}
+	sr, err := agent.Stream(context.Background(), []*schema.Message{schema.UserMessage(question)})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer sr.Close()
+
+	var b strings.Builder
+	chunks := 0
+	for {
+		msg, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		b.WriteString(msg.Content)
+		chunks++
+	}
+	return b.String(), chunks
+}
+
+func TestToolAgentInvokesTool(t *testing.T) {
+	search := &searchStub{}
+	cm := fake.NewChatModel(
+		fake.ToolCallReply(fake.ToolCall("duckduckgo_text_search", `{"query":"北京天气"}`)),
+		fake.Reply("北京今天晴，适合出行。"),
+	)
+	cm.ChunkSize = 3
+
+	answer, chunks := streamAnswer(t, cm, search, "查询北京天气,给出建议")
+	if answer != "北京今天晴，适合出行。" || chunks < 2 {
+		t.Errorf("answer = %q in %d chunks", answer, chunks)
+	}
+	if len(search.args) != 1 || !strings.Contains(search.args[0], "北京天气") {
+		t.Fatalf("search args = %q", search.args)
+	}
+
+	// 第二次调用模型时带上原问题、tool_calls 与工具结果
+	calls := cm.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("model called %d times, want 2", len(calls))
+	}
+	input := calls[1].Input
+	if len(input) != 3 || input[1].Role != schema.Assistant || len(input[1].ToolCalls) != 1 ||
+		input[2].Role != schema.Tool || !strings.Contains(input[2].Content, "晴") {
+		t.Errorf("final model input = %v", input)
+	}
+	if len(calls[0].Tools) != 1 || calls[0].Tools[0].Name != "duckduckgo_text_search" {
+		t.Errorf("tools bound = %v", calls[0].Tools)
+	}
+}
+
+func TestToolAgentAnswersDirectly(t *testing.T) {
+	search := &searchStub{}
+	cm := fake.NewChatModel(fake.Reply("你好，我可以帮你查询天气。"))
+	cm.ChunkSize = 2
+
+	answer, chunks := streamAnswer(t, cm, search, "你好")
+	if answer != "你好，我可以帮你查询天气。" || chunks < 2 {
+		t.Errorf("answer = %q in %d chunks", answer, chunks)
+	}
+	if len(search.args) != 0 || len(cm.Calls()) != 1 {
+		t.Errorf("search args = %q, model calls = %d", search.args, len(cm.Calls()))
+	}
+}
+
+func TestToolAgentInvoke(t *testing.T) {
+	search := &searchStub{}
+	cm := fake.NewChatModel(
+		fake.ToolCallReply(fake.ToolCall("duckduckgo_text_search", `{"query":"上海天气"}`)),
+		fake.Reply("上海今天晴。"),
+	)
+	agent, err := newToolAgent(context.Background(), cm, []tool.BaseTool{search})
+	if err != nil {
+		t.Fatalf("newToolAgent() error = %v", err)
+	}
+	msg, err := agent.Invoke(context.Background(), []*schema.Message{schema.UserMessage("上海天气")})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if msg.Content != "上海今天晴。" || len(search.args) != 1 {
+		t.Errorf("answer = %q, search args = %q", msg.Content, search.args)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+
+	"common/config"
+)
+
+// cfg 当前生效的配置，main 启动时从配置文件、环境变量与命令行参数加载
+var cfg = defaultConfig()
+
+// appConfig chain 的全部配置
+type appConfig struct {
+	LLM  config.LLM `yaml:"llm"`
+	Game gameConfig `yaml:"game"`
+}
+
+// gameConfig 问答游戏的规则
+type gameConfig struct {
+	Topic      string  `yaml:"topic" usage:"出题主题"`
+	Difficulty string  `yaml:"difficulty" usage:"题目难度，如 简单、中等、困难"`
+	Rounds     int     `yaml:"rounds" usage:"每局题目数量"`
+	Points     int     `yaml:"points" usage:"每题满分"`
+	PassScore  float64 `yaml:"pass_score" usage:"得分率达到该值视为答对，0-1"`
+	Rules      string  `yaml:"rules" usage:"补充给出题与评分的规则说明"`
+	SavePath   string  `yaml:"save_path" usage:"游戏进度文件，为空时不保存"`
+}
+
+// defaultConfig 默认配置
+func defaultConfig() *appConfig {
+	return &appConfig{
+		LLM: config.DefaultLLM(),
+		Game: gameConfig{
+			Topic:      "中国古代历史",
+			Difficulty: "中等",
+			Rounds:     5,
+			Points:     10,
+			PassScore:  0.6,
+			Rules:      "题目只有一个明确答案，答案不超过二十个字；评分时意思正确即可，不要求字面一致。",
+			SavePath:   "./game_session.json",
+		},
+	}
+}
+
+// Validate 校验全部配置
+func (c *appConfig) Validate() error {
+	return errors.Join(c.LLM.Validate(), c.Game.validate())
+}
+
+// validate 校验游戏规则
+func (c *gameConfig) validate() error {
+	var errs []error
+	if c.Topic == "" {
+		errs = append(errs, errors.New("未配置 game.topic"))
+	}
+	if c.Rounds <= 0 || c.Points <= 0 {
+		errs = append(errs, errors.New("game.rounds 与 game.points 必须大于 0"))
+	}
+	if c.PassScore < 0 || c.PassScore > 1 {
+		errs = append(errs, errors.New("game.pass_score 必须在 0 到 1 之间"))
+	}
+	return errors.Join(errs...)
+}
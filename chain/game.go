@@ -1,4 +1,299 @@
 package main
 
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrGameOver 本局题目已全部作答
+var ErrGameOver = errors.New("本局已结束")
+
+// jsonObjectPattern 提取模型输出中的 JSON 对象，兼容 ```json 代码块
+var jsonObjectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+// Question 一道题目，参考答案只用于评分，不展示给玩家
+type Question struct {
+	Text   string `json:"question"`
+	Answer string `json:"answer"`
+}
+
+// Grade 模型给出的结构化评分
+type Grade struct {
+	Correct  bool    `json:"correct"`
+	Score    float64 `json:"score"` // 0-1 的得分率
+	Feedback string  `json:"feedback"`
+}
+
+// Round 一轮作答记录
+type Round struct {
+	Question Question `json:"question"`
+	Reply    string   `json:"reply"`
+	Grade    Grade    `json:"grade"`
+	Points   int      `json:"points"`
+}
+
+// Game 由大模型出题与评分的问答游戏，保存本局的轮次、得分与玩家作答历史
+// 每次出题与作答后写入进度文件，重新启动时从上次的位置继续
 type Game struct {
+	Topic     string    `json:"topic"`
+	History   []Round   `json:"history"`
+	Score     int       `json:"score"`
+	Pending   *Question `json:"pending,omitempty"` // 已出题、尚未作答
+	UpdatedAt time.Time `json:"updated_at"`
+
+	rules    gameConfig
+	question compose.Runnable[map[string]any, *Question]
+	grade    compose.Runnable[map[string]any, *Grade]
+}
+
+// NewGame 按规则编译出题与评分 Chain，开始新的一局
+func NewGame(ctx context.Context, chatModel model.BaseChatModel, rules gameConfig) (*Game, error) {
+	question, err := newQuestionChain(ctx, chatModel)
+	if err != nil {
+		return nil, err
+	}
+	grade, err := newGradeChain(ctx, chatModel)
+	if err != nil {
+		return nil, err
+	}
+	return &Game{Topic: rules.Topic, rules: rules, question: question, grade: grade}, nil
+}
+
+// LoadGame 从进度文件恢复未完成的一局，没有进度、主题已变更或上一局已结束时开始新的一局
+func LoadGame(ctx context.Context, chatModel model.BaseChatModel, rules gameConfig) (*Game, error) {
+	g, err := NewGame(ctx, chatModel, rules)
+	if err != nil || rules.SavePath == "" {
+		return g, err
+	}
+
+	b, err := os.ReadFile(rules.SavePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取游戏进度失败: %w", err)
+	}
+	var saved Game
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, fmt.Errorf("解析游戏进度失败: %w", err)
+	}
+	if saved.Topic != rules.Topic || len(saved.History) >= rules.Rounds {
+		return g, nil
+	}
+	g.History, g.Score, g.Pending, g.UpdatedAt = saved.History, saved.Score, saved.Pending, saved.UpdatedAt
+	return g, nil
+}
+
+// Finished 是否已答完本局全部题目
+func (g *Game) Finished() bool {
+	return len(g.History) >= g.rules.Rounds
+}
+
+// MaxScore 本局满分
+func (g *Game) MaxScore() int {
+	return g.rules.Rounds * g.rules.Points
+}
+
+// NextQuestion 返回待作答的题目，没有时让模型出一道新题
+func (g *Game) NextQuestion(ctx context.Context) (*Question, error) {
+	if g.Finished() {
+		return nil, ErrGameOver
+	}
+	if g.Pending != nil {
+		return g.Pending, nil
+	}
+
+	asked := make([]string, len(g.History))
+	for i, r := range g.History {
+		asked[i] = "- " + r.Question.Text
+	}
+	q, err := g.question.Invoke(ctx, map[string]any{
+		"topic":      g.rules.Topic,
+		"difficulty": g.rules.Difficulty,
+		"rules":      g.rules.Rules,
+		"asked":      strings.Join(asked, "\n"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("出题失败: %w", err)
+	}
+	g.Pending = q
+	return q, g.Save()
+}
+
+// Answer 让模型为玩家对当前题目的回答评分，记录本轮并保存进度
+func (g *Game) Answer(ctx context.Context, reply string) (*Round, error) {
+	if g.Pending == nil {
+		return nil, errors.New("当前没有待作答的题目")
+	}
+
+	grade, err := g.grade.Invoke(ctx, map[string]any{
+		"question":  g.Pending.Text,
+		"reference": g.Pending.Answer,
+		"reply":     reply,
+		"rules":     g.rules.Rules,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("评分失败: %w", err)
+	}
+	// 是否答对按规则中的 pass_score 判定，避免模型给出的 correct 与分数自相矛盾
+	grade.Score = math.Max(0, math.Min(1, grade.Score))
+	grade.Correct = grade.Score >= g.rules.PassScore
+
+	round := Round{
+		Question: *g.Pending,
+		Reply:    reply,
+		Grade:    *grade,
+		Points:   int(math.Round(grade.Score * float64(g.rules.Points))),
+	}
+	g.History = append(g.History, round)
+	g.Score += round.Points
+	g.Pending = nil
+	return &round, g.Save()
+}
+
+// Save 写入进度文件，先写临时文件再替换，避免中途失败留下半个文件
+func (g *Game) Save() error {
+	if g.rules.SavePath == "" {
+		return nil
+	}
+	g.UpdatedAt = time.Now()
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化游戏进度失败: %w", err)
+	}
+	tmp := g.rules.SavePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("写入游戏进度失败: %w", err)
+	}
+	if err := os.Rename(tmp, g.rules.SavePath); err != nil {
+		return fmt.Errorf("写入游戏进度失败: %w", err)
+	}
+	return nil
+}
+
+// newQuestionChain 出题：提示词模板 → 模型 → 解析 JSON 题目
+func newQuestionChain(ctx context.Context, chatModel model.BaseChatModel) (compose.Runnable[map[string]any, *Question], error) {
+	tmpl := prompt.FromMessages(schema.FString,
+		schema.SystemMessage("你是知识问答游戏的出题人。主题：{topic}，难度：{difficulty}。规则：{rules}\n"+
+			`只输出 JSON 对象，例如 {{"question": "题目", "answer": "参考答案"}}，不要输出其它内容。`),
+		schema.UserMessage("已经出过的题目：\n{asked}\n\n请出一道新题，不要与已出过的题目重复。"),
+	)
+
+	chain := compose.NewChain[map[string]any, *Question]()
+	chain.
+		AppendChatTemplate(tmpl, compose.WithNodeName("question_prompt")).
+		AppendChatModel(chatModel, compose.WithNodeName("question_model")).
+		AppendLambda(compose.InvokableLambda(func(_ context.Context, msg *schema.Message) (*Question, error) {
+			var q Question
+			if err := parseJSONObject(msg.Content, &q); err != nil {
+				return nil, err
+			}
+			if q.Text == "" || q.Answer == "" {
+				return nil, fmt.Errorf("题目或参考答案为空: %s", msg.Content)
+			}
+			return &q, nil
+		}), compose.WithNodeName("parse_question"))
+
+	runnable, err := chain.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译出题 Chain 失败: %w", err)
+	}
+	return runnable, nil
+}
+
+// newGradeChain 评分：提示词模板 → 模型 → 解析 JSON 评分
+func newGradeChain(ctx context.Context, chatModel model.BaseChatModel) (compose.Runnable[map[string]any, *Grade], error) {
+	tmpl := prompt.FromMessages(schema.FString,
+		schema.SystemMessage("你是知识问答游戏的裁判，对照参考答案为玩家的回答评分。规则：{rules}\n"+
+			`只输出 JSON 对象，例如 {{"correct": true, "score": 0.8, "feedback": "一句话点评"}}，`+
+			"score 为 0 到 1 的得分率，不要输出其它内容。"),
+		schema.UserMessage("题目：{question}\n参考答案：{reference}\n玩家回答：{reply}"),
+	)
+
+	chain := compose.NewChain[map[string]any, *Grade]()
+	chain.
+		AppendChatTemplate(tmpl, compose.WithNodeName("grade_prompt")).
+		AppendChatModel(chatModel, compose.WithNodeName("grade_model")).
+		AppendLambda(compose.InvokableLambda(func(_ context.Context, msg *schema.Message) (*Grade, error) {
+			var g Grade
+			if err := parseJSONObject(msg.Content, &g); err != nil {
+				return nil, err
+			}
+			return &g, nil
+		}), compose.WithNodeName("parse_grade"))
+
+	runnable, err := chain.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译评分 Chain 失败: %w", err)
+	}
+	return runnable, nil
+}
+
+// parseJSONObject 从模型输出中提取并解析 JSON 对象
+func parseJSONObject(content string, v any) error {
+	raw := jsonObjectPattern.FindString(content)
+	if raw == "" {
+		return fmt.Errorf("模型输出不是 JSON 对象: %s", content)
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("解析模型输出失败: %w", err)
+	}
+	return nil
+}
+
+// runGame 交互式进行一局游戏，输入 exit 保存进度后退出
+func runGame(ctx context.Context, g *Game, in io.Reader, out io.Writer) error {
+	if len(g.History) > 0 {
+		fmt.Fprintf(out, "继续上次的游戏：已答 %d/%d 题，得分 %d\n", len(g.History), g.rules.Rounds, g.Score)
+	} else {
+		fmt.Fprintf(out, "开始游戏：主题 %s，共 %d 题，输入 exit 保存并退出\n", g.Topic, g.rules.Rounds)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for !g.Finished() {
+		q, err := g.NextQuestion(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\n第 %d 题：%s\n> ", len(g.History)+1, q.Text)
+
+		var reply string
+		for reply == "" {
+			if !scanner.Scan() {
+				return g.Save()
+			}
+			reply = strings.TrimSpace(scanner.Text())
+		}
+		if reply == "exit" || reply == "quit" {
+			fmt.Fprintln(out, "进度已保存")
+			return g.Save()
+		}
+
+		round, err := g.Answer(ctx, reply)
+		if err != nil {
+			return err
+		}
+		verdict := "答错了"
+		if round.Grade.Correct {
+			verdict = "答对了"
+		}
+		fmt.Fprintf(out, "%s，得 %d 分。%s\n参考答案：%s\n", verdict, round.Points, round.Grade.Feedback, round.Question.Answer)
+	}
+
+	fmt.Fprintf(out, "\n游戏结束，总分 %d/%d\n", g.Score, g.MaxScore())
+	return nil
 }
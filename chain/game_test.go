@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+// testRules 两题一局、每题 10 分的规则，进度写入临时目录
+func testRules(t *testing.T) gameConfig {
+	rules := defaultConfig().Game
+	rules.Rounds = 2
+	rules.SavePath = filepath.Join(t.TempDir(), "game.json")
+	return rules
+}
+
+func TestGameRound(t *testing.T) {
+	cm := fake.NewChatModel(
+		fake.Reply("```json\n{\"question\": \"秦朝的开国皇帝是谁？\", \"answer\": \"秦始皇嬴政\"}\n```"),
+		fake.Reply(`{"correct": true, "score": 0.95, "feedback": "回答正确"}`),
+		fake.Reply(`{"question": "唐朝的都城是哪里？", "answer": "长安"}`),
+		fake.Reply(`{"correct": true, "score": 0.3, "feedback": "不是洛阳"}`),
+	)
+	ctx := context.Background()
+	g, err := NewGame(ctx, cm, testRules(t))
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+
+	q, err := g.NextQuestion(ctx)
+	if err != nil {
+		t.Fatalf("NextQuestion() error = %v", err)
+	}
+	if q.Text != "秦朝的开国皇帝是谁？" || q.Answer != "秦始皇嬴政" {
+		t.Errorf("question = %+v", q)
+	}
+	if sys := cm.Calls()[0].Input[0].Content; !strings.Contains(sys, `{"question": "题目"`) || !strings.Contains(sys, "中国古代历史") {
+		t.Errorf("question system prompt = %q", sys)
+	}
+	// 未作答前重复获取不会再次出题
+	if again, _ := g.NextQuestion(ctx); again != q || len(cm.Calls()) != 1 {
+		t.Errorf("pending question regenerated")
+	}
+
+	round, err := g.Answer(ctx, "嬴政")
+	if err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+	if !round.Grade.Correct || round.Points != 10 || g.Score != 10 {
+		t.Errorf("round = %+v, score = %d", round, g.Score)
+	}
+	grading := cm.LastInput()
+	if !strings.Contains(grading[1].Content, "秦始皇嬴政") || !strings.Contains(grading[1].Content, "嬴政") {
+		t.Errorf("grading input = %v", grading)
+	}
+
+	if _, err := g.NextQuestion(ctx); err != nil {
+		t.Fatalf("NextQuestion() error = %v", err)
+	}
+	// 出题时带上已出过的题目，避免重复
+	if asked := cm.LastInput()[1].Content; !strings.Contains(asked, "秦朝的开国皇帝是谁？") {
+		t.Errorf("question prompt = %q", asked)
+	}
+
+	// 分数低于 pass_score 时即使模型判为正确也算答错
+	round, err = g.Answer(ctx, "洛阳")
+	if err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+	if round.Grade.Correct || round.Points != 3 || g.Score != 13 {
+		t.Errorf("round = %+v, score = %d", round, g.Score)
+	}
+
+	if !g.Finished() || g.MaxScore() != 20 {
+		t.Errorf("finished = %v, max = %d", g.Finished(), g.MaxScore())
+	}
+	if _, err := g.NextQuestion(ctx); !errors.Is(err, ErrGameOver) {
+		t.Errorf("NextQuestion() after last round error = %v", err)
+	}
+}
+
+func TestLoadGameResumes(t *testing.T) {
+	ctx := context.Background()
+	rules := testRules(t)
+	cm := fake.NewChatModel(
+		fake.Reply(`{"question": "汉朝的开国皇帝是谁？", "answer": "刘邦"}`),
+		fake.Reply(`{"correct": true, "score": 1, "feedback": "正确"}`),
+		fake.Reply(`{"question": "宋朝的开国皇帝是谁？", "answer": "赵匡胤"}`),
+	)
+	g, err := NewGame(ctx, cm, rules)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	g.NextQuestion(ctx)
+	g.Answer(ctx, "刘邦")
+	pending, _ := g.NextQuestion(ctx)
+
+	// 重新加载后保留得分、历史与未作答的题目
+	resumed, err := LoadGame(ctx, fake.NewChatModel(), rules)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if resumed.Score != 10 || len(resumed.History) != 1 || resumed.History[0].Reply != "刘邦" {
+		t.Errorf("resumed = %+v", resumed)
+	}
+	if resumed.Pending == nil || resumed.Pending.Text != pending.Text {
+		t.Errorf("pending = %+v, want %+v", resumed.Pending, pending)
+	}
+
+	// 更换主题后开始新的一局
+	rules.Topic = "唐诗"
+	fresh, err := LoadGame(ctx, fake.NewChatModel(), rules)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if fresh.Score != 0 || len(fresh.History) != 0 || fresh.Pending != nil {
+		t.Errorf("fresh = %+v", fresh)
+	}
+}
+
+func TestGameInvalidModelOutput(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		reply *schema.Message
+	}{
+		{name: "不是 JSON", reply: fake.Reply("秦朝的开国皇帝是谁？")},
+		{name: "缺少参考答案", reply: fake.Reply(`{"question": "秦朝的开国皇帝是谁？"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGame(ctx, fake.NewChatModel(tt.reply), testRules(t))
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			if _, err := g.NextQuestion(ctx); err == nil {
+				t.Errorf("NextQuestion() error = nil")
+			}
+			if g.Pending != nil {
+				t.Errorf("pending = %+v", g.Pending)
+			}
+		})
+	}
+}
+
+func TestRunGame(t *testing.T) {
+	ctx := context.Background()
+	rules := testRules(t)
+	cm := fake.NewChatModel(
+		fake.Reply(`{"question": "明朝的开国皇帝是谁？", "answer": "朱元璋"}`),
+		fake.Reply(`{"correct": true, "score": 1, "feedback": "正确"}`),
+		fake.Reply(`{"question": "清朝的最后一位皇帝是谁？", "answer": "溥仪"}`),
+	)
+	g, err := NewGame(ctx, cm, rules)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+
+	var out strings.Builder
+	if err := runGame(ctx, g, strings.NewReader("朱元璋\n\nexit\n"), &out); err != nil {
+		t.Fatalf("runGame() error = %v", err)
+	}
+	for _, want := range []string{"第 1 题：明朝的开国皇帝是谁？", "答对了，得 10 分", "第 2 题：清朝的最后一位皇帝是谁？", "进度已保存"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	resumed, err := LoadGame(ctx, fake.NewChatModel(), rules)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if resumed.Score != 10 || resumed.Pending == nil {
+		t.Errorf("resumed = %+v", resumed)
+	}
+}
@@ -2,147 +2,91 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"time"
 
-	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
 	duckduckgo "github.com/cloudwego/eino-ext/components/tool/duckduckgo/v2"
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-)
-
-var (
-	// 千问llm
-	llmKey = os.Getenv("DASHSCOPE_API_KEY")
-	llmApi = "https://dashscope.aliyuncs.com/compatible-mode/v1" //千问系列API
 
-	embeddingModel = "text-embedding-v3" // Embedding 模型配置
-	chatModel      = "qwen-plus"         // chat模型
+	"common/config"
+	"common/errs"
+	"common/provider"
 )
 
 func main() {
 	ctx := context.Background()
 
-	// Create configuration
-	config := &duckduckgo.Config{
-		MaxResults: 3, // Limit to return 3 results
-		Region:     duckduckgo.RegionCN,
-	}
-
-	// Create search client
-	textSearchTool, err := duckduckgo.NewTextSearchTool(ctx, config)
-	if err != nil {
-		log.Fatalf("NewTool of duckduckgo failed, err=%v", err)
-	}
-	// Create search request
-	searchReq := &duckduckgo.TextSearchRequest{
-		Query: "北京天气",
+	// 子命令: go run . [game] [-config config.yaml] [-game.topic 唐诗] ...
+	cmd, args := "", os.Args[1:]
+	if len(args) > 0 && args[0] == "game" {
+		cmd, args = args[0], args[1:]
 	}
+	config.MustLoad(flag.NewFlagSet("chain", flag.ExitOnError), args, cfg)
 
-	jsonReq, err := json.Marshal(searchReq)
-	if err != nil {
-		log.Fatalf("Marshal of search request failed, err=%v", err)
+	// 问答游戏: go run . game
+	if cmd == "game" {
+		errs.Exit(playGame(ctx))
+		return
 	}
+	errs.Exit(run(ctx, "查询北京天气,给出建议", os.Stdout))
+}
 
-	toolInfo, err := textSearchTool.Info(ctx)
+// playGame 创建模型并在终端进行一局问答游戏
+func playGame(ctx context.Context) error {
+	chatModel, err := provider.NewChatModel(ctx, &cfg.LLM)
 	if err != nil {
-		log.Fatalf("Info of duckduckgo failed, err=%v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "创建聊天模型", err)
 	}
-	// Execute search
-	resp, err := textSearchTool.InvokableRun(ctx, string(jsonReq))
+	g, err := LoadGame(ctx, chatModel, cfg.Game)
 	if err != nil {
-		log.Fatalf("Search of duckduckgo failed, err=%v", err)
+		return err
 	}
+	return runGame(ctx, g, os.Stdin, os.Stdout)
+}
 
-	var searchResp duckduckgo.TextSearchResponse
-	if err := json.Unmarshal([]byte(resp), &searchResp); err != nil {
-		log.Fatalf("Unmarshal of search response failed, err=%v", err)
-	}
-
-	// Print results
-	fmt.Println("Search Results:")
-	fmt.Println("==============")
-	for i, result := range searchResp.Results {
-		fmt.Printf("\n%d. Title: %s\n", i+1, result.Title)
-		fmt.Printf("   URL: %s\n", result.URL)
-		fmt.Printf("\n%d. Summary: %s\n", i+1, result.Summary)
-	}
-	fmt.Println("")
-	fmt.Println("==============")
-
-	// 创建工具节点
-	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
-		Tools: []tool.BaseTool{
-			textSearchTool,
-		},
+// run 由模型决定是否搜索，再结合搜索结果流式输出建议
+func run(ctx context.Context, question string, w io.Writer) error {
+	// Create search client
+	textSearchTool, err := duckduckgo.NewTextSearchTool(ctx, &duckduckgo.Config{
+		MaxResults: 3, // Limit to return 3 results
+		Region:     duckduckgo.RegionCN,
 	})
 	if err != nil {
-		panic(err)
+		return errs.Wrap(errs.ErrToolCall, "创建 duckduckgo 搜索工具", err)
 	}
 
-	// // Mock LLM 输出作为输入
-	// input := &schema.Message{
-	// 	Role: schema.Assistant,
-	// 	ToolCalls: []schema.ToolCall{
-	// 		{
-	// 			Function: schema.FunctionCall{
-	// 				Name:      "duckduckgo_text_search",
-	// 				Arguments: `{"query": "深圳", "date": "tomorrow"}`,
-	// 			},
-	// 		},
-	// 	},
-	// }
-
-	// toolMessages, err := toolsNode.Invoke(ctx, input)
-	// fmt.Println("tool messages: ", toolMessages)
-
-	chatModel, err := createChatModel(ctx)
+	chatModel, err := provider.NewChatModel(ctx, &cfg.LLM)
 	if err != nil {
-		log.Fatalf("Failed to create chat model: %v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "创建聊天模型", err)
 	}
-	chatModel.BindTools([]*schema.ToolInfo{toolInfo})
 
-	// Build the chain with the ChatModel and the Tools node.
-	// First the tools node, then the chat model to properly handle the types
-	chain := compose.NewChain[[]*schema.Message, []*schema.Message]()
-	chain.
-		AppendToolsNode(toolsNode, compose.WithNodeName("search")).
-		AppendChatModel(chatModel, compose.WithNodeName("chat_model"))
-
-	// Compile the chain to obtain the agent.
-	agent, err := chain.Compile(ctx)
+	agent, err := newToolAgent(ctx, chatModel, []tool.BaseTool{textSearchTool})
 	if err != nil {
-		log.Fatalf("Failed to compile chain: %v", err)
+		return err
 	}
-	outMsg, err := agent.Invoke(ctx, []*schema.Message{{
-		Role:    schema.User,
-		Content: "查询北京天气,给出建议",
-	}})
 
+	sr, err := agent.Stream(ctx, []*schema.Message{
+		schema.SystemMessage("你可以使用搜索工具查询实时信息，需要时先搜索再回答。"),
+		schema.UserMessage(question),
+	})
 	if err != nil {
-		log.Fatalf("Failed to invoke agent: %v", err)
+		return fmt.Errorf("运行 Chain 失败: %w", err)
 	}
-
-	// Since the output is []*schema.Message, we need to print the content of the first message
-	if len(outMsg) > 0 {
-		fmt.Println("outMsg: ", outMsg[0].Content)
-	} else {
-		fmt.Println("No output message received")
+	defer sr.Close()
+
+	for {
+		msg, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("接收回答失败: %w", err)
+		}
+		fmt.Fprint(w, msg.Content)
 	}
-}
-
-// createChatModel 创建对话模型
-func createChatModel(ctx context.Context) (*chatOpenAi.ChatModel, error) {
-	// 创建 LLM
-	llm, err := chatOpenAi.NewChatModel(ctx, &chatOpenAi.ChatModelConfig{
-		APIKey:  llmKey,
-		Model:   chatModel,
-		Timeout: 60 * time.Second, // 添加超时
-		BaseURL: llmApi,
-	})
-	return llm, err
+	fmt.Fprintln(w)
+	return nil
 }
@@ -0,0 +1,361 @@
+// Package config 加载各示例共用的配置
+//
+// 加载顺序为 默认值 → 配置文件（YAML/TOML） → 环境变量 → 命令行参数，后者覆盖前者。
+// 配置结构体的叶子字段通过标签声明来源：
+//
+//	yaml:"chat_model"       配置文件中的键，嵌套结构体用点号拼接，如 llm.chat_model，同时作为命令行参数名
+//	env:"A,B"               环境变量，按顺序取第一个非空值
+//	usage:"..."             命令行参数说明
+//	yaml:",inline"          嵌入的结构体，字段直接展开到外层，不增加键名前缀
+//
+// 结构体列表（如 []Subject）只能在配置文件中填写，按元素结构体的 yaml 标签解码，文件中出现时整体替换默认值。
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"common/errs"
+)
+
+// EnvConfigPath 指定配置文件路径的环境变量
+const EnvConfigPath = "EINO_CONFIG"
+
+// DefaultFiles 未指定配置文件时，从当前目录逐级向上查找的文件名
+var DefaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Validator 可自校验的配置
+type Validator interface {
+	Validate() error
+}
+
+// field 配置结构体中的一个叶子字段
+type field struct {
+	key   string
+	env   []string
+	usage string
+	value reflect.Value
+	// block 为结构体列表，只从配置文件读取
+	block bool
+}
+
+// flagValue 记录命令行参数的原始值，在文件与环境变量之后再写入字段
+type flagValue struct {
+	f   *field
+	raw string
+	set bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil || v.f == nil {
+		return ""
+	}
+	return formatValue(v.f.value)
+}
+
+func (v *flagValue) Set(s string) error {
+	if err := setValue(reflect.New(v.f.value.Type()).Elem(), s); err != nil {
+		return err
+	}
+	v.raw, v.set = s, true
+	return nil
+}
+
+// Load 把配置加载到 v，v 为结构体指针且已填好默认值
+// fs 上会注册 -config 以及每个配置项对应的参数（如 -llm.chat_model），args 为待解析的命令行参数；
+// 解析后剩余的位置参数可通过 fs.Args() 获取
+func Load(fs *flag.FlagSet, args []string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("配置必须是结构体指针，实际为 %T", v)
+	}
+	fields := collectFields(rv.Elem(), "")
+
+	path := fs.String("config", "", "配置文件路径（YAML 或 TOML），也可通过环境变量 "+EnvConfigPath+" 指定")
+	flags := make([]*flagValue, len(fields))
+	for i, f := range fields {
+		flags[i] = &flagValue{f: f}
+		if f.block {
+			continue
+		}
+		usage := f.usage
+		if len(f.env) > 0 {
+			usage = strings.TrimSpace(usage + "（环境变量 " + strings.Join(f.env, "、") + "）")
+		}
+		fs.Var(flags[i], f.key, usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	file, err := resolvePath(*path)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		if err := loadFile(file, fields); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		for _, name := range f.env {
+			s := os.Getenv(name)
+			if s == "" {
+				continue
+			}
+			if err := setValue(f.value, s); err != nil {
+				return fmt.Errorf("环境变量 %s 的值无效: %w", name, err)
+			}
+			break
+		}
+	}
+
+	for _, fv := range flags {
+		if fv.set {
+			if err := setValue(fv.f.value, fv.raw); err != nil {
+				return fmt.Errorf("参数 -%s 的值无效: %w", fv.f.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MustLoad 加载并校验配置，失败时输出错误并退出
+func MustLoad(fs *flag.FlagSet, args []string, v Validator) {
+	if err := Load(fs, args, v); err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(errs.ExitConfig)
+	}
+	if err := v.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "配置无效: %v\n", err)
+		os.Exit(errs.ExitConfig)
+	}
+}
+
+// collectFields 递归收集叶子字段，键名取 yaml 标签，缺省为小写字段名
+func collectFields(v reflect.Value, prefix string) []*field {
+	var fields []*field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		key := prefix + name
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			sub := key + "."
+			if slices.Contains(strings.Split(opts, ","), "inline") {
+				sub = prefix
+			}
+			fields = append(fields, collectFields(v.Field(i), sub)...)
+			continue
+		}
+
+		f := &field{key: key, usage: sf.Tag.Get("usage"), value: v.Field(i)}
+		if sf.Type.Kind() == reflect.Slice && sf.Type.Elem().Kind() == reflect.Struct {
+			f.block = true
+			fields = append(fields, f)
+			continue
+		}
+		if env := sf.Tag.Get("env"); env != "" {
+			f.env = strings.Split(env, ",")
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// resolvePath 确定配置文件路径：参数 > 环境变量 > 从当前目录向上查找，都没有时返回空
+func resolvePath(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("配置文件不可用: %w", err)
+		}
+		return path, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", nil
+	}
+	for {
+		for _, name := range DefaultFiles {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+		// 到仓库根目录为止
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadFile 读取配置文件并写入对应字段，文件中与当前命令无关的键会被忽略，便于多个示例共用一份配置
+func loadFile(path string, fields []*field) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	case ".toml":
+		err = toml.Unmarshal(b, &raw)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+
+	values := map[string]any{}
+	flatten(raw, "", values)
+	for _, f := range fields {
+		v, ok := values[f.key]
+		if !ok || v == nil {
+			continue
+		}
+		if f.block {
+			if err := decodeBlock(f.value, v); err != nil {
+				return fmt.Errorf("配置文件 %s 中 %s 的值无效: %w", path, f.key, err)
+			}
+			continue
+		}
+		if err := setValue(f.value, fileValueString(v)); err != nil {
+			return fmt.Errorf("配置文件 %s 中 %s 的值无效: %w", path, f.key, err)
+		}
+	}
+	return nil
+}
+
+// flatten 将嵌套的 map 展开为点号分隔的键
+func flatten(m map[string]any, prefix string, out map[string]any) {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			flatten(sub, prefix+k+".", out)
+			continue
+		}
+		out[prefix+k] = v
+	}
+}
+
+// decodeBlock 把配置文件中的列表按 yaml 标签解码到结构体列表，替换原有的值
+func decodeBlock(v reflect.Value, raw any) error {
+	b, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	items := reflect.New(v.Type())
+	if err := yaml.Unmarshal(b, items.Interface()); err != nil {
+		return err
+	}
+	v.Set(items.Elem())
+	return nil
+}
+
+// fileValueString 把配置文件中的值转换为与命令行参数相同的字符串形式，列表以逗号拼接
+func fileValueString(v any) string {
+	if list, ok := v.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// setValue 按字段类型解析字符串并赋值
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的列表类型: %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的配置类型: %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue 把字段值格式化为命令行参数形式，用于 -h 输出默认值
+func formatValue(v reflect.Value) string {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LLM 大模型服务配置，未填写的项使用所选服务商的默认值，见 Providers
+type LLM struct {
+	Provider       string        `yaml:"provider" env:"LLM_PROVIDER" usage:"大模型服务商：dashscope、openai、azure、ollama、openai-compatible"`
+	APIKey         string        `yaml:"api_key" env:"LLM_API_KEY" usage:"大模型服务密钥，为空时读取服务商对应的环境变量，如 DASHSCOPE_API_KEY"`
+	BaseURL        string        `yaml:"base_url" env:"LLM_BASE_URL" usage:"大模型服务地址"`
+	APIVersion     string        `yaml:"api_version" env:"LLM_API_VERSION" usage:"Azure OpenAI API 版本"`
+	ChatModel      string        `yaml:"chat_model" env:"LLM_CHAT_MODEL" usage:"对话模型，Azure 填部署名"`
+	EmbeddingModel string        `yaml:"embedding_model" env:"LLM_EMBEDDING_MODEL" usage:"向量模型，Azure 填部署名"`
+	EmbeddingDims  int           `yaml:"embedding_dims" usage:"向量维度，0 使用模型默认维度，仅部分模型支持指定"`
+	Timeout        time.Duration `yaml:"timeout" usage:"请求超时"`
+	Resilience     Resilience    `yaml:"resilience"`
+}
+
+// Resilience 对话与向量化调用的重试、限流与熔断
+type Resilience struct {
+	MaxRetries       int           `yaml:"max_retries" usage:"429、5xx 与网络错误的最大重试次数"`
+	InitialBackoff   time.Duration `yaml:"initial_backoff" usage:"首次重试前的等待时间，之后按指数增长"`
+	MaxBackoff       time.Duration `yaml:"max_backoff" usage:"单次重试等待时间上限"`
+	QPS              float64       `yaml:"qps" usage:"每秒请求数上限，0 使用服务商默认值，小于 0 不限"`
+	TPM              int           `yaml:"tpm" usage:"每分钟 token 数上限，0 使用服务商默认值，小于 0 不限"`
+	FailureThreshold int           `yaml:"failure_threshold" usage:"连续失败多少次后熔断，0 为不熔断"`
+	Cooldown         time.Duration `yaml:"cooldown" usage:"熔断后等待多久放行一次试探请求"`
+}
+
+// DefaultLLM 返回默认配置：千问系列
+func DefaultLLM() LLM {
+	return LLM{
+		Provider: "dashscope",
+		Resilience: Resilience{
+			MaxRetries:       3,
+			InitialBackoff:   500 * time.Millisecond,
+			MaxBackoff:       10 * time.Second,
+			FailureThreshold: 5,
+			Cooldown:         30 * time.Second,
+		},
+	}
+}
+
+// Validate 用服务商默认值补全未填写的项，并校验必填项
+func (c *LLM) Validate() error {
+	p, ok := Providers[c.Provider]
+	if !ok {
+		return fmt.Errorf("未知的 llm.provider %q，可选: %s", c.Provider, strings.Join(ProviderNames(), "、"))
+	}
+
+	if c.APIKey == "" && p.APIKeyEnv != "" {
+		c.APIKey = os.Getenv(p.APIKeyEnv)
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = p.BaseURL
+	}
+	if c.APIVersion == "" {
+		c.APIVersion = p.APIVersion
+	}
+	if c.ChatModel == "" {
+		c.ChatModel = p.ChatModel
+	}
+	if c.EmbeddingModel == "" {
+		c.EmbeddingModel = p.EmbeddingModel
+	}
+	if c.Timeout == 0 {
+		c.Timeout = p.Timeout
+	}
+	if c.Resilience.QPS == 0 {
+		c.Resilience.QPS = p.QPS
+	}
+	if c.Resilience.TPM == 0 {
+		c.Resilience.TPM = p.TPM
+	}
+
+	var errs []error
+	if p.RequireAPIKey && c.APIKey == "" {
+		hint := "LLM_API_KEY"
+		if p.APIKeyEnv != "" {
+			hint = p.APIKeyEnv + " 或 " + hint
+		}
+		errs = append(errs, fmt.Errorf("未配置 llm.api_key：请设置环境变量 %s，或在配置文件中填写", hint))
+	}
+	if c.BaseURL == "" {
+		errs = append(errs, fmt.Errorf("未配置 llm.base_url：%s 需要指定服务地址", c.Provider))
+	}
+	if c.ChatModel == "" {
+		errs = append(errs, fmt.Errorf("未配置 llm.chat_model：%s 没有默认对话模型", c.Provider))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("llm.timeout 必须大于 0"))
+	}
+	if c.EmbeddingDims < 0 {
+		errs = append(errs, errors.New("llm.embedding_dims 不能小于 0"))
+	}
+	if c.Resilience.MaxRetries < 0 || c.Resilience.InitialBackoff < 0 || c.Resilience.MaxBackoff < 0 {
+		errs = append(errs, errors.New("llm.resilience 的重试次数与等待时间不能小于 0"))
+	}
+	if c.Resilience.FailureThreshold > 0 && c.Resilience.Cooldown <= 0 {
+		errs = append(errs, errors.New("启用熔断时 llm.resilience.cooldown 必须大于 0"))
+	}
+	return errors.Join(errs...)
+}
+
+// Base 只需要大模型配置的命令直接使用
+type Base struct {
+	LLM LLM `yaml:"llm"`
+}
+
+// NewBase 返回带默认值的 Base
+func NewBase() *Base {
+	return &Base{LLM: DefaultLLM()}
+}
+
+// Validate 校验必填项
+func (c *Base) Validate() error {
+	return c.LLM.Validate()
+}
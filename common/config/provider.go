@@ -0,0 +1,72 @@
+package config
+
+import (
+	"sort"
+	"time"
+)
+
+// Provider 大模型服务商的默认值与必填项
+type Provider struct {
+	BaseURL        string
+	ChatModel      string
+	EmbeddingModel string
+	APIVersion     string
+	Timeout        time.Duration
+	APIKeyEnv      string // 该服务商惯用的密钥环境变量，llm.api_key 为空时读取
+	RequireAPIKey  bool
+	QPS            float64 // 默认每秒请求数上限，0 为不限
+	TPM            int     // 默认每分钟 token 数上限，0 为不限
+}
+
+// Providers 内置服务商，可在启动前增删
+var Providers = map[string]Provider{
+	// 阿里云百炼的 OpenAI 兼容接口
+	"dashscope": {
+		BaseURL:        "https://dashscope.aliyuncs.com/compatible-mode/v1",
+		ChatModel:      "qwen-plus",
+		EmbeddingModel: "text-embedding-v3",
+		Timeout:        60 * time.Second,
+		APIKeyEnv:      "DASHSCOPE_API_KEY",
+		RequireAPIKey:  true,
+		QPS:            10,
+	},
+	// 限流按最低使用档位估计，账户档位更高时可在配置中调大
+	"openai": {
+		BaseURL:        "https://api.openai.com/v1",
+		ChatModel:      "gpt-4o-mini",
+		EmbeddingModel: "text-embedding-3-small",
+		Timeout:        60 * time.Second,
+		APIKeyEnv:      "OPENAI_API_KEY",
+		RequireAPIKey:  true,
+		QPS:            5,
+		TPM:            200000,
+	},
+	// Azure OpenAI：base_url 为资源地址，chat_model 与 embedding_model 填部署名
+	"azure": {
+		APIVersion:    "2024-06-01",
+		Timeout:       60 * time.Second,
+		APIKeyEnv:     "AZURE_OPENAI_API_KEY",
+		RequireAPIKey: true,
+	},
+	// 本地 Ollama 的 OpenAI 兼容接口，无需密钥
+	"ollama": {
+		BaseURL:        "http://localhost:11434/v1",
+		ChatModel:      "qwen2.5:7b",
+		EmbeddingModel: "nomic-embed-text",
+		Timeout:        120 * time.Second,
+	},
+	// 任意 OpenAI 兼容服务，如 vLLM、LM Studio、Xinference
+	"openai-compatible": {
+		Timeout: 120 * time.Second,
+	},
+}
+
+// ProviderNames 返回已注册的服务商名称
+func ProviderNames() []string {
+	names := make([]string, 0, len(Providers))
+	for name := range Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,272 @@
+// Package embedcache 为 Embedder 提供本地向量缓存，相同模型、维度与文本只向量化一次
+package embedcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketVectors = []byte("vectors") // 缓存键 -> 访问序号 + 向量
+	bucketLRU     = []byte("lru")     // 访问序号 -> 缓存键，按序号淘汰最久未使用的向量
+)
+
+// Config 缓存配置
+type Config struct {
+	Path       string // bbolt 文件路径
+	Model      string // 向量模型名，调用时通过 embedding.WithModel 指定的模型优先
+	Dims       int    // 向量维度，0 表示模型默认维度
+	MaxEntries int    // 最多缓存的向量数，超出时淘汰最久未使用的，<=0 不限
+}
+
+// Stats 缓存命中统计
+type Stats struct {
+	Hits    int64 // 命中的文本数
+	Misses  int64 // 未命中的文本数，同一批中重复的文本按出现次数计
+	Entries int   // 当前缓存的向量数
+}
+
+// HitRate 命中率
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Embedder 带本地缓存的 Embedder，缓存键为 (模型, 维度, sha256(文本))
+type Embedder struct {
+	embedder embedding.Embedder
+	db       *bolt.DB
+	conf     Config
+
+	mu      sync.Mutex // 串行化写事务中的序号分配与淘汰
+	seq     uint64     // 最近一次访问的序号
+	entries int
+
+	hits, misses atomic.Int64
+}
+
+// New 打开或创建缓存文件，包装 e
+func New(e embedding.Embedder, conf *Config) (*Embedder, error) {
+	if conf.Path == "" {
+		return nil, fmt.Errorf("未配置向量缓存路径")
+	}
+	db, err := bolt.Open(conf.Path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开向量缓存失败: %w", err)
+	}
+
+	c := &Embedder{embedder: e, db: db, conf: *conf}
+	err = db.Update(func(tx *bolt.Tx) error {
+		vectors, err := tx.CreateBucketIfNotExists(bucketVectors)
+		if err != nil {
+			return err
+		}
+		lru, err := tx.CreateBucketIfNotExists(bucketLRU)
+		if err != nil {
+			return err
+		}
+		c.entries = vectors.Stats().KeyN
+		if k, _ := lru.Cursor().Last(); k != nil {
+			c.seq = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化向量缓存失败: %w", err)
+	}
+	return c, nil
+}
+
+// EmbedStrings 先查缓存，只把未命中的文本交给被包装的 Embedder，结果写回缓存
+func (c *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	model := c.conf.Model
+	options := embedding.GetCommonOptions(&embedding.Options{Model: &model}, opts...)
+	if options.Model != nil {
+		model = *options.Model
+	}
+
+	keys := make([][]byte, len(texts))
+	for i, text := range texts {
+		keys[i] = c.key(model, text)
+	}
+
+	vectors := make([][]float64, len(texts))
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketVectors)
+		for i, key := range keys {
+			if v := b.Get(key); v != nil {
+				vectors[i] = decodeVector(v[8:])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取向量缓存失败: %w", err)
+	}
+
+	// 命中与未命中按输入位置计数，未命中的文本去重后一次性向量化
+	var (
+		missTexts []string
+		missIdx   = map[string][]int{}
+		misses    int
+	)
+	for i, text := range texts {
+		if vectors[i] != nil {
+			continue
+		}
+		misses++
+		if _, ok := missIdx[text]; !ok {
+			missTexts = append(missTexts, text)
+		}
+		missIdx[text] = append(missIdx[text], i)
+	}
+	c.hits.Add(int64(len(texts) - misses))
+	c.misses.Add(int64(misses))
+
+	fresh := map[string][]float64{}
+	if len(missTexts) > 0 {
+		embedded, err := c.embedder.EmbedStrings(ctx, missTexts, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(embedded) != len(missTexts) {
+			return nil, fmt.Errorf("向量数量 %d 与文本数量 %d 不一致", len(embedded), len(missTexts))
+		}
+		for j, text := range missTexts {
+			fresh[string(c.key(model, text))] = embedded[j]
+			for _, i := range missIdx[text] {
+				vectors[i] = embedded[j]
+			}
+		}
+	}
+
+	if err := c.store(keys, fresh); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// store 写入新向量并刷新命中向量的访问序号，超出上限时淘汰最久未使用的向量
+func (c *Embedder) store(keys [][]byte, fresh map[string][]float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		vectors, lru := tx.Bucket(bucketVectors), tx.Bucket(bucketLRU)
+		touched := map[string]bool{}
+		for _, key := range keys {
+			if touched[string(key)] {
+				continue
+			}
+			touched[string(key)] = true
+
+			old := vectors.Get(key)
+			var body []byte
+			if v, ok := fresh[string(key)]; ok {
+				body = encodeVector(v)
+			} else if old != nil {
+				body = append([]byte(nil), old[8:]...)
+			} else {
+				// 读取后被并发淘汰，下次访问重新向量化
+				continue
+			}
+			if old != nil {
+				if err := lru.Delete(old[:8]); err != nil {
+					return err
+				}
+			} else {
+				c.entries++
+			}
+
+			c.seq++
+			seq := binary.BigEndian.AppendUint64(nil, c.seq)
+			if err := vectors.Put(key, append(seq, body...)); err != nil {
+				return err
+			}
+			if err := lru.Put(seq, key); err != nil {
+				return err
+			}
+		}
+
+		if c.conf.MaxEntries <= 0 {
+			return nil
+		}
+		cur := lru.Cursor()
+		for k, key := cur.First(); k != nil && c.entries > c.conf.MaxEntries; k, key = cur.Next() {
+			if err := vectors.Delete(key); err != nil {
+				return err
+			}
+			if err := cur.Delete(); err != nil {
+				return err
+			}
+			c.entries--
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入向量缓存失败: %w", err)
+	}
+	return nil
+}
+
+// key 缓存键：模型|维度|sha256(文本)
+func (c *Embedder) key(model, text string) []byte {
+	sum := sha256.Sum256([]byte(text))
+	return []byte(fmt.Sprintf("%s|%d|%s", model, c.conf.Dims, hex.EncodeToString(sum[:])))
+}
+
+// Stats 返回命中统计
+func (c *Embedder) Stats() Stats {
+	c.mu.Lock()
+	entries := c.entries
+	c.mu.Unlock()
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+// Close 关闭缓存文件
+func (c *Embedder) Close() error {
+	return c.db.Close()
+}
+
+// GetType 沿用被包装 Embedder 的类型
+func (c *Embedder) GetType() string {
+	if t, ok := components.GetType(c.embedder); ok {
+		return t
+	}
+	return ""
+}
+
+// IsCallbacksEnabled 被包装 Embedder 自行触发回调时，编排层不再重复触发
+func (c *Embedder) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(c.embedder)
+}
+
+func encodeVector(v []float64) []byte {
+	b := make([]byte, 0, 8*len(v))
+	for _, f := range v {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float64 {
+	v := make([]float64, len(b)/8)
+	for i := range v {
+		v[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package embedcache
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+
+	"common/fake"
+)
+
+// countingEmbedder 记录实际向量化的文本数
+type countingEmbedder struct {
+	fake.Embedder
+	texts atomic.Int64
+}
+
+func (e *countingEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	e.texts.Add(int64(len(texts)))
+	return e.Embedder.EmbedStrings(ctx, texts, opts...)
+}
+
+func newTestCache(t *testing.T, inner embedding.Embedder, conf Config) *Embedder {
+	t.Helper()
+	if conf.Path == "" {
+		conf.Path = filepath.Join(t.TempDir(), "cache.db")
+	}
+	c, err := New(inner, &conf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestEmbedStrings(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{Embedder: fake.Embedder{Dims: 16}}
+	c := newTestCache(t, inner, Config{Model: "m"})
+
+	tests := []struct {
+		name       string
+		texts      []string
+		opts       []embedding.Option
+		wantCalled int64
+		wantHits   int64
+		wantMisses int64
+	}{
+		{name: "首次全部未命中，批内重复只向量化一次", texts: []string{"风寒", "风热", "风寒"}, wantCalled: 2, wantMisses: 3},
+		{name: "再次请求全部命中", texts: []string{"风热", "风寒"}, wantCalled: 0, wantHits: 2},
+		{name: "部分命中", texts: []string{"风寒", "气滞"}, wantCalled: 1, wantHits: 1, wantMisses: 1},
+		{name: "重复的未命中文本按位置计数", texts: []string{"湿热", "风寒", "湿热", "湿热"}, wantCalled: 1, wantHits: 1, wantMisses: 3},
+		{name: "换模型不命中", texts: []string{"风寒"}, opts: []embedding.Option{embedding.WithModel("other")}, wantCalled: 1, wantMisses: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, stats := inner.texts.Load(), c.Stats()
+			got, err := c.EmbedStrings(ctx, tt.texts, tt.opts...)
+			if err != nil {
+				t.Fatalf("EmbedStrings() error = %v", err)
+			}
+			want, _ := inner.Embedder.EmbedStrings(ctx, tt.texts)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("缓存返回的向量与直接向量化的不一致")
+			}
+			if called := inner.texts.Load() - before; called != tt.wantCalled {
+				t.Errorf("embedded %d texts, want %d", called, tt.wantCalled)
+			}
+			after := c.Stats()
+			if hits, misses := after.Hits-stats.Hits, after.Misses-stats.Misses; hits != tt.wantHits || misses != tt.wantMisses {
+				t.Errorf("hits = %d, misses = %d, want %d, %d", hits, misses, tt.wantHits, tt.wantMisses)
+			}
+		})
+	}
+}
+
+func TestPersistAndEvict(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	inner := &countingEmbedder{Embedder: fake.Embedder{Dims: 16}}
+
+	c, err := New(inner, &Config{Path: path, Model: "m", MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, text := range []string{"a", "b", "a", "c"} { // 写入 c 时淘汰最久未使用的 b
+		if _, err := c.EmbedStrings(ctx, []string{text}); err != nil {
+			t.Fatalf("EmbedStrings() error = %v", err)
+		}
+	}
+	if got := c.Stats().Entries; got != 2 {
+		t.Errorf("Entries = %d, want 2", got)
+	}
+	c.Close()
+
+	// 重新打开后保留 a、c
+	c = newTestCache(t, inner, Config{Path: path, Model: "m", MaxEntries: 2})
+	if got := c.Stats().Entries; got != 2 {
+		t.Errorf("reopened Entries = %d, want 2", got)
+	}
+	before := inner.texts.Load()
+	if _, err := c.EmbedStrings(ctx, []string{"a", "c"}); err != nil {
+		t.Fatalf("EmbedStrings() error = %v", err)
+	}
+	if _, err := c.EmbedStrings(ctx, []string{"b"}); err != nil {
+		t.Fatalf("EmbedStrings() error = %v", err)
+	}
+	if called := inner.texts.Load() - before; called != 1 {
+		t.Errorf("embedded %d texts after reopen, want 1 (only the evicted b)", called)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 1 || s.HitRate() < 0.66 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
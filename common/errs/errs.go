@@ -0,0 +1,99 @@
+// Package errs 各示例共用的错误分类与命令行退出码
+// 调用方用 errors.Is 判断失败原因，底层错误仍可通过 errors.Is/As 取得
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 错误分类
+var (
+	// ErrNoDocuments 没有加载到文档，或检索结果为空
+	ErrNoDocuments = errors.New("没有可用的文档")
+	// ErrRetrieval 向量存储连接、索引或检索失败
+	ErrRetrieval = errors.New("检索失败")
+	// ErrToolCall 工具创建、连接或执行失败
+	ErrToolCall = errors.New("工具调用失败")
+	// ErrModelUnavailable 模型创建或调用失败，包括重试用尽与熔断
+	ErrModelUnavailable = errors.New("模型不可用")
+	// ErrConfig 配置或参数错误，如模型服务以 401、404 拒绝请求
+	ErrConfig = errors.New("配置错误")
+)
+
+// 命令行退出码
+const (
+	ExitError            = 1 // 未分类的错误
+	ExitConfig           = 2 // 配置或参数错误
+	ExitNoDocuments      = 3
+	ExitRetrieval        = 4
+	ExitToolCall         = 5
+	ExitModelUnavailable = 6
+)
+
+// Error 带分类的错误，Kind 为上面的分类之一，Op 为失败的操作
+type Error struct {
+	Kind error
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Op + "失败: " + e.Kind.Error()
+	}
+	return e.Op + "失败: " + e.Err.Error()
+}
+
+// Unwrap 同时暴露分类与底层错误
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Wrap 把 err 归入 kind 分类，err 为 nil 时返回 nil
+func Wrap(kind error, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Op: op, Err: err}
+}
+
+// New 创建没有底层错误的分类错误
+func New(kind error, op string) error {
+	return &Error{Kind: kind, Op: op}
+}
+
+// ExitCode 按错误分类返回退出码，err 为 nil 时返回 0
+// 同时属于多个分类时取更接近根因的一个，如向量模型不可用导致的检索失败按模型不可用处理，
+// 密钥错误导致的模型调用失败按配置错误处理
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrConfig):
+		return ExitConfig
+	case errors.Is(err, ErrNoDocuments):
+		return ExitNoDocuments
+	case errors.Is(err, ErrModelUnavailable):
+		return ExitModelUnavailable
+	case errors.Is(err, ErrToolCall):
+		return ExitToolCall
+	case errors.Is(err, ErrRetrieval):
+		return ExitRetrieval
+	default:
+		return ExitError
+	}
+}
+
+// Exit 输出错误并以对应退出码结束进程，err 为 nil 时直接返回
+func Exit(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+	os.Exit(ExitCode(err))
+}
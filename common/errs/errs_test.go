@@ -0,0 +1,44 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: 0},
+		{name: "未分类", err: cause, want: ExitError},
+		{name: "没有文档", err: New(ErrNoDocuments, "加载文档"), want: ExitNoDocuments},
+		{name: "检索", err: Wrap(ErrRetrieval, "连接 ES", cause), want: ExitRetrieval},
+		{name: "外层再包装", err: fmt.Errorf("回答失败: %w", Wrap(ErrToolCall, "搜索", cause)), want: ExitToolCall},
+		{name: "模型", err: Wrap(ErrModelUnavailable, "生成回答", cause), want: ExitModelUnavailable},
+		{name: "取根因", err: Wrap(ErrRetrieval, "索引文档", Wrap(ErrModelUnavailable, "调用向量模型", cause)), want: ExitModelUnavailable},
+		{name: "配置错误优先", err: Wrap(ErrModelUnavailable, "生成回答", Wrap(ErrConfig, "调用对话模型", cause)), want: ExitConfig},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("timeout")
+	err := Wrap(ErrModelUnavailable, "生成回答", cause)
+	if !errors.Is(err, ErrModelUnavailable) || !errors.Is(err, cause) {
+		t.Errorf("errors.Is 应同时匹配分类与底层错误: %v", err)
+	}
+	if err.Error() != "生成回答失败: timeout" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if Wrap(ErrRetrieval, "检索", nil) != nil {
+		t.Errorf("Wrap(nil) 应返回 nil")
+	}
+}
@@ -0,0 +1,177 @@
+// Package fake 提供离线测试用的确定性组件：按脚本回复的对话模型与哈希 Embedder
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrScriptExhausted 脚本回复已用完
+var ErrScriptExhausted = errors.New("fake: 脚本回复已用完")
+
+// RespondFunc 根据输入动态生成回复
+type RespondFunc func(ctx context.Context, input []*schema.Message) (*schema.Message, error)
+
+// ChatModel 实现 model.ToolCallingChatModel，按脚本依次返回预设回复，并记录每次调用的输入
+// 设置 Respond 时优先使用 Respond，否则依次消费 Replies
+type ChatModel struct {
+	Replies []*schema.Message
+	Respond RespondFunc
+	// ChunkSize 流式输出时每个分片的字符数，<=0 时按 1 个字符切分
+	ChunkSize int
+
+	tools []*schema.ToolInfo
+	state *chatState
+}
+
+// chatState WithTools 派生出的模型共享脚本进度与调用记录
+type chatState struct {
+	mu    sync.Mutex
+	next  int
+	calls []Call
+}
+
+// Call 一次调用的记录
+type Call struct {
+	Input  []*schema.Message
+	Tools  []*schema.ToolInfo
+	Stream bool
+}
+
+// NewChatModel 创建依次返回 replies 的模型
+func NewChatModel(replies ...*schema.Message) *ChatModel {
+	return &ChatModel{Replies: replies}
+}
+
+// Reply 构造普通回复
+func Reply(content string) *schema.Message {
+	return schema.AssistantMessage(content, nil)
+}
+
+// ToolCall 构造一次工具调用，args 为参数 JSON
+func ToolCall(name, args string) schema.ToolCall {
+	return schema.ToolCall{Type: "function", Function: schema.FunctionCall{Name: name, Arguments: args}}
+}
+
+// ToolCallReply 构造工具调用回复，未设置的调用 ID 与序号按顺序生成
+func ToolCallReply(calls ...schema.ToolCall) *schema.Message {
+	for i := range calls {
+		if calls[i].ID == "" {
+			calls[i].ID = fmt.Sprintf("call_%d", i+1)
+		}
+		if calls[i].Index == nil {
+			index := i
+			calls[i].Index = &index
+		}
+	}
+	return schema.AssistantMessage("", calls)
+}
+
+func (m *ChatModel) getState() *chatState {
+	if m.state == nil {
+		m.state = &chatState{}
+	}
+	return m.state
+}
+
+// reply 记录调用并取出下一条回复
+func (m *ChatModel) reply(ctx context.Context, input []*schema.Message, stream bool) (*schema.Message, error) {
+	st := m.getState()
+	st.mu.Lock()
+	st.calls = append(st.calls, Call{Input: input, Tools: m.tools, Stream: stream})
+	if m.Respond != nil {
+		st.mu.Unlock()
+		return m.Respond(ctx, input)
+	}
+	defer st.mu.Unlock()
+	if st.next >= len(m.Replies) {
+		return nil, ErrScriptExhausted
+	}
+	msg := m.Replies[st.next]
+	st.next++
+	return msg, nil
+}
+
+// Generate 返回下一条回复
+func (m *ChatModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	msg, err := m.reply(ctx, input, false)
+	if err != nil {
+		return nil, err
+	}
+	out := *msg
+	return &out, nil
+}
+
+// Stream 把下一条回复按 ChunkSize 切分后流式返回，工具调用放在最后一个分片中
+func (m *ChatModel) Stream(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.reply(ctx, input, true)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := m.split(msg)
+	sr, sw := schema.Pipe[*schema.Message](len(chunks))
+	go func() {
+		defer sw.Close()
+		for _, c := range chunks {
+			if sw.Send(c, nil) {
+				return
+			}
+		}
+	}()
+	return sr, nil
+}
+
+// split 把回复切分为流式分片
+func (m *ChatModel) split(msg *schema.Message) []*schema.Message {
+	size := m.ChunkSize
+	if size <= 0 {
+		size = 1
+	}
+	runes := []rune(msg.Content)
+	var chunks []*schema.Message
+	for start := 0; start < len(runes); start += size {
+		chunks = append(chunks, &schema.Message{Role: msg.Role, Content: string(runes[start:min(start+size, len(runes))])})
+	}
+	if len(msg.ToolCalls) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, &schema.Message{Role: msg.Role, ToolCalls: msg.ToolCalls, ResponseMeta: msg.ResponseMeta})
+	} else {
+		chunks[len(chunks)-1].ResponseMeta = msg.ResponseMeta
+	}
+	return chunks
+}
+
+// WithTools 返回绑定了工具的模型，与原模型共享脚本进度与调用记录
+func (m *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	bound := *m
+	bound.state = m.getState()
+	bound.tools = tools
+	return &bound, nil
+}
+
+// Calls 返回所有调用记录
+func (m *ChatModel) Calls() []Call {
+	st := m.getState()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return append([]Call(nil), st.calls...)
+}
+
+// LastInput 返回最近一次调用的输入，未调用时返回 nil
+func (m *ChatModel) LastInput() []*schema.Message {
+	calls := m.Calls()
+	if len(calls) == 0 {
+		return nil
+	}
+	return calls[len(calls)-1].Input
+}
+
+// GetType 组件类型，用于回调
+func (m *ChatModel) GetType() string {
+	return "Fake"
+}
@@ -0,0 +1,71 @@
+package fake
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// Embedder 基于字符 n-gram 哈希的确定性 Embedder，不依赖网络
+// 相同文本得到相同向量，字面越相近的文本余弦相似度越高
+type Embedder struct {
+	Dims int // 向量维度，<=0 时为 256
+}
+
+// EmbedStrings 把每个文本的一元、二元字符组哈希到固定维度并归一化
+func (e *Embedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	dims := e.Dims
+	if dims <= 0 {
+		dims = 256
+	}
+
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vec := make([]float64, dims)
+		var prev rune
+		for _, r := range text {
+			if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+				prev = 0
+				continue
+			}
+			addHashedFeature(vec, string(r))
+			if prev != 0 {
+				addHashedFeature(vec, string([]rune{prev, r}))
+			}
+			prev = r
+		}
+		normalize(vec)
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+// addHashedFeature 将特征哈希到向量的某一维，用哈希的最高位决定正负以减少冲突偏差
+func addHashedFeature(vec []float64, feature string) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	sign := 1.0
+	if sum>>63 == 1 {
+		sign = -1
+	}
+	vec[sum%uint64(len(vec))] += sign
+}
+
+// normalize L2 归一化，零向量保持不变
+func normalize(vec []float64) {
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
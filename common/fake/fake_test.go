@@ -0,0 +1,126 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+var _ model.ToolCallingChatModel = (*ChatModel)(nil)
+
+func TestChatModelGenerate(t *testing.T) {
+	ctx := context.Background()
+	m := NewChatModel(
+		ToolCallReply(ToolCall("search", `{"query":"北京天气"}`)),
+		Reply("晴"),
+	)
+	bound, err := m.WithTools([]*schema.ToolInfo{{Name: "search"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := bound.Generate(ctx, []*schema.Message{schema.UserMessage("天气")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.ToolCalls) != 1 || first.ToolCalls[0].Function.Name != "search" || first.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("unexpected tool calls: %+v", first.ToolCalls)
+	}
+
+	// 原模型与绑定工具后的模型共享脚本进度
+	second, err := m.Generate(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Content != "晴" {
+		t.Fatalf("got %q, want 晴", second.Content)
+	}
+	if _, err := m.Generate(ctx, nil); !errors.Is(err, ErrScriptExhausted) {
+		t.Fatalf("got %v, want ErrScriptExhausted", err)
+	}
+
+	calls := m.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want 3", len(calls))
+	}
+	if len(calls[0].Tools) != 1 || len(calls[1].Tools) != 0 {
+		t.Fatalf("tools not recorded per model: %+v", calls)
+	}
+}
+
+func TestChatModelStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      *schema.Message
+		chunkSize  int
+		wantChunks int
+	}{
+		{name: "按字符切分", reply: Reply("风寒感冒"), chunkSize: 1, wantChunks: 4},
+		{name: "按两个字符切分", reply: Reply("风寒感冒。"), chunkSize: 2, wantChunks: 3},
+		{name: "工具调用单独成片", reply: ToolCallReply(ToolCall("search", `{}`)), chunkSize: 2, wantChunks: 1},
+		{name: "空回复", reply: Reply(""), chunkSize: 2, wantChunks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewChatModel(tt.reply)
+			m.ChunkSize = tt.chunkSize
+			sr, err := m.Stream(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sr.Close()
+
+			var chunks []*schema.Message
+			for {
+				c, err := sr.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				chunks = append(chunks, c)
+			}
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("got %d chunks, want %d", len(chunks), tt.wantChunks)
+			}
+			full, err := schema.ConcatMessages(chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if full.Content != tt.reply.Content || len(full.ToolCalls) != len(tt.reply.ToolCalls) {
+				t.Fatalf("concat mismatch: %+v", full)
+			}
+		})
+	}
+}
+
+func TestEmbedder(t *testing.T) {
+	e := &Embedder{Dims: 64}
+	vectors, err := e.EmbedStrings(context.Background(), []string{"风寒感冒", "风寒感冒", "肝郁脾虚", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cos := func(a, b []float64) float64 {
+		var dot float64
+		for i := range a {
+			dot += a[i] * b[i]
+		}
+		return dot
+	}
+	if got := cos(vectors[0], vectors[1]); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("same text cosine = %v, want 1", got)
+	}
+	if got := cos(vectors[0], vectors[2]); got > 0.5 {
+		t.Fatalf("different text cosine = %v, want < 0.5", got)
+	}
+	if got := cos(vectors[3], vectors[3]); got != 0 {
+		t.Fatalf("empty text should embed to zero vector, got norm %v", got)
+	}
+}
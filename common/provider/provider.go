@@ -0,0 +1,130 @@
+// Package provider 按服务商名称创建对话模型与 Embedder，服务商的默认值见 config.Providers
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino-ext/components/embedding/openai"
+	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+
+	"common/config"
+	"common/resilience"
+)
+
+// ChatModelFactory 根据配置创建对话模型
+type ChatModelFactory func(ctx context.Context, c *config.LLM) (model.ToolCallingChatModel, error)
+
+// EmbedderFactory 根据配置创建 Embedder
+type EmbedderFactory func(ctx context.Context, c *config.LLM) (embedding.Embedder, error)
+
+type factories struct {
+	chat     ChatModelFactory
+	embedder EmbedderFactory
+}
+
+// registry 内置服务商都提供 OpenAI 兼容接口，Azure 需要额外的鉴权与部署名映射
+var registry = map[string]factories{
+	"dashscope":         {chat: newOpenAIChatModel, embedder: newOpenAIEmbedder},
+	"openai":            {chat: newOpenAIChatModel, embedder: newOpenAIEmbedder},
+	"azure":             {chat: newOpenAIChatModel, embedder: newOpenAIEmbedder},
+	"ollama":            {chat: newOpenAIChatModel, embedder: newOpenAIEmbedder},
+	"openai-compatible": {chat: newOpenAIChatModel, embedder: newOpenAIEmbedder},
+}
+
+var (
+	policiesMu sync.Mutex
+	// policies 每个服务商一个 Policy，对话模型与 Embedder 共享限流配额与熔断状态
+	policies = map[string]*resilience.Policy{}
+)
+
+// policyFor 返回服务商共享的 Policy，首次调用时按 c.Resilience 创建
+func policyFor(c *config.LLM) *resilience.Policy {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	key := c.Provider + "|" + c.BaseURL
+	p, ok := policies[key]
+	if !ok {
+		p = resilience.NewPolicy(c.Resilience)
+		policies[key] = p
+	}
+	return p
+}
+
+// Register 注册或覆盖服务商，需在加载配置前调用；embedder 可为 nil，表示不支持向量化
+func Register(name string, defaults config.Provider, chat ChatModelFactory, embedder EmbedderFactory) {
+	config.Providers[name] = defaults
+	registry[name] = factories{chat: chat, embedder: embedder}
+}
+
+// NewChatModel 按 c.Provider 创建对话模型，c 需已通过 Validate 补全默认值
+// 返回的模型带有 c.Resilience 配置的重试、限流与熔断
+func NewChatModel(ctx context.Context, c *config.LLM) (model.ToolCallingChatModel, error) {
+	f, ok := registry[c.Provider]
+	if !ok {
+		return nil, fmt.Errorf("未知的大模型服务商: %s", c.Provider)
+	}
+	cm, err := f.chat(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("创建 %s 对话模型失败: %w", c.Provider, err)
+	}
+	return resilience.WrapChatModel(cm, policyFor(c)), nil
+}
+
+// NewEmbedder 按 c.Provider 创建 Embedder，c 需已通过 Validate 补全默认值
+// 返回的 Embedder 与同一服务商的对话模型共用限流配额与熔断状态
+func NewEmbedder(ctx context.Context, c *config.LLM) (embedding.Embedder, error) {
+	f, ok := registry[c.Provider]
+	if !ok {
+		return nil, fmt.Errorf("未知的大模型服务商: %s", c.Provider)
+	}
+	if f.embedder == nil {
+		return nil, fmt.Errorf("服务商 %s 不支持向量化", c.Provider)
+	}
+	if c.EmbeddingModel == "" {
+		return nil, fmt.Errorf("未配置 llm.embedding_model：%s 没有默认向量模型", c.Provider)
+	}
+	e, err := f.embedder(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("创建 %s embedder 失败: %w", c.Provider, err)
+	}
+	return resilience.WrapEmbedder(e, policyFor(c)), nil
+}
+
+// newOpenAIChatModel 通过 OpenAI 兼容接口创建对话模型
+func newOpenAIChatModel(ctx context.Context, c *config.LLM) (model.ToolCallingChatModel, error) {
+	conf := &chatOpenAi.ChatModelConfig{
+		APIKey:  c.APIKey,
+		Model:   c.ChatModel,
+		Timeout: c.Timeout,
+		BaseURL: c.BaseURL,
+	}
+	if c.Provider == "azure" {
+		conf.ByAzure = true
+		conf.APIVersion = c.APIVersion
+		// chat_model 直接作为部署名，不做改写
+		conf.AzureModelMapperFunc = func(model string) string { return model }
+	}
+	return chatOpenAi.NewChatModel(ctx, conf)
+}
+
+// newOpenAIEmbedder 通过 OpenAI 兼容接口创建 Embedder
+func newOpenAIEmbedder(ctx context.Context, c *config.LLM) (embedding.Embedder, error) {
+	conf := &openai.EmbeddingConfig{
+		APIKey:  c.APIKey,
+		Model:   c.EmbeddingModel,
+		Timeout: c.Timeout,
+		BaseURL: c.BaseURL,
+	}
+	if c.EmbeddingDims > 0 {
+		conf.Dimensions = &c.EmbeddingDims
+	}
+	if c.Provider == "azure" {
+		conf.ByAzure = true
+		conf.APIVersion = c.APIVersion
+	}
+	return openai.NewEmbedder(ctx, conf)
+}
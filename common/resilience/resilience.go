@@ -0,0 +1,249 @@
+// Package resilience 为对话模型与 Embedder 提供指数退避重试、QPS/TPM 限流与熔断
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/meguminnnnnnnnn/go-openai"
+	"golang.org/x/time/rate"
+
+	"common/config"
+)
+
+// ErrCircuitOpen 服务连续失败后熔断，冷却期内的请求直接返回该错误
+var ErrCircuitOpen = errors.New("服务连续失败，已熔断")
+
+// Policy 重试、限流与熔断策略，同一服务商的对话模型与 Embedder 共用一个 Policy
+type Policy struct {
+	conf     config.Resilience
+	requests *rate.Limiter // 请求数限流，nil 为不限
+	tokens   *rate.Limiter // token 数限流，nil 为不限
+	breaker  *breaker
+}
+
+// NewPolicy 按配置创建策略，QPS、TPM 小于等于 0 时不限流
+func NewPolicy(conf config.Resilience) *Policy {
+	p := &Policy{
+		conf:    conf,
+		breaker: &breaker{threshold: conf.FailureThreshold, cooldown: conf.Cooldown, now: time.Now},
+	}
+	if conf.QPS > 0 {
+		p.requests = rate.NewLimiter(rate.Limit(conf.QPS), max(1, int(math.Ceil(conf.QPS))))
+	}
+	if conf.TPM > 0 {
+		p.tokens = rate.NewLimiter(rate.Limit(float64(conf.TPM)/60), conf.TPM)
+	}
+	return p
+}
+
+// Do 在限流与熔断保护下执行 fn，遇到可重试错误时按指数退避重试
+// tokens 为本次请求预估消耗的 token 数，用于 TPM 限流
+func (p *Policy) Do(ctx context.Context, tokens int, fn func(ctx context.Context) error) error {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := p.wait(ctx, tokens); err != nil {
+			return err
+		}
+		if !p.breaker.allow() {
+			if lastErr != nil {
+				return fmt.Errorf("%w，最近一次错误: %v", ErrCircuitOpen, lastErr)
+			}
+			return ErrCircuitOpen
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			p.breaker.record(false)
+			return nil
+		}
+		// 调用方取消或超时，不计入服务失败
+		if ctx.Err() != nil {
+			p.breaker.release()
+			return err
+		}
+
+		retryable := Retryable(err)
+		p.breaker.record(retryable)
+		if !retryable {
+			return err
+		}
+		if attempt >= p.conf.MaxRetries {
+			if attempt == 0 {
+				return err
+			}
+			return fmt.Errorf("重试 %d 次后仍失败: %w", attempt, err)
+		}
+
+		lastErr = err
+		delay := p.backoff(attempt)
+		log.Printf("模型调用失败，%s 后第 %d 次重试: %v", delay.Round(time.Millisecond), attempt+1, err)
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Charge 记入请求完成后才得知的 token 消耗，如模型输出的 token 数
+func (p *Policy) Charge(tokens int) {
+	if p.tokens == nil || tokens <= 0 {
+		return
+	}
+	p.tokens.ReserveN(time.Now(), min(tokens, p.tokens.Burst()))
+}
+
+// wait 等待请求数与 token 数配额，ctx 取消时立即返回
+func (p *Policy) wait(ctx context.Context, tokens int) error {
+	if p.requests != nil {
+		if err := p.requests.Wait(ctx); err != nil {
+			return fmt.Errorf("等待请求配额失败: %w", err)
+		}
+	}
+	if p.tokens != nil && tokens > 0 {
+		if err := p.tokens.WaitN(ctx, min(tokens, p.tokens.Burst())); err != nil {
+			return fmt.Errorf("等待 token 配额失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// backoff 第 attempt 次失败后的等待时间：指数增长，加入随机抖动避免多个请求同时重试
+func (p *Policy) backoff(attempt int) time.Duration {
+	d := p.conf.InitialBackoff << attempt
+	if d <= 0 || (p.conf.MaxBackoff > 0 && d > p.conf.MaxBackoff) {
+		d = p.conf.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep 等待 d，ctx 取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Retryable 判断错误是否值得重试：429、408、5xx 与网络错误
+func Retryable(err error) bool {
+	if code := statusCode(err); code > 0 {
+		return retryableStatus(code)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
+
+// clientError 判断是否为不可重试的 4xx 错误，如 400、401、403、404
+func clientError(err error) bool {
+	code := statusCode(err)
+	return code >= 400 && code < 500 && !retryableStatus(code)
+}
+
+// statusCode 取出模型服务返回的 HTTP 状态码，不是 HTTP 错误时返回 0
+func statusCode(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0 {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// breaker 连续失败 threshold 次后熔断，冷却 cooldown 后放行一个试探请求，成功则恢复
+type breaker struct {
+	mu        sync.Mutex
+	threshold int // 小于等于 0 时不熔断
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures int
+	openedAt time.Time
+	probing  bool // 半开状态下已有试探请求在执行
+}
+
+// allow 判断当前是否放行请求
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record 记录一次请求结果，failed 表示服务端失败
+func (b *breaker) record(failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	if b.failures++; b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// release 请求未得到结果，释放试探名额，不改变熔断状态
+func (b *breaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// EstimateTokens 粗略估算 token 数：中文约一字一个 token，英文约四个字符一个 token
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return other + (ascii+3)/4
+}
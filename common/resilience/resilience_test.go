@@ -0,0 +1,193 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/meguminnnnnnnnn/go-openai"
+
+	"common/config"
+	"common/errs"
+	"common/fake"
+)
+
+func statusError(code int) error {
+	return fmt.Errorf("failed to create chat completion: %w", &openai.APIError{HTTPStatusCode: code, Message: "mock"})
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "429", err: statusError(429), want: true},
+		{name: "503", err: statusError(503), want: true},
+		{name: "400", err: statusError(400), want: false},
+		{name: "401", err: &openai.RequestError{HTTPStatusCode: 401}, want: false},
+		{name: "502 RequestError", err: &openai.RequestError{HTTPStatusCode: 502}, want: true},
+		{name: "普通错误", err: errors.New("boom"), want: false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("%s: Retryable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// scriptedModel 依次返回 errs 中的错误，用完后返回成功
+func scriptedModel(errs ...error) *fake.ChatModel {
+	cm := &fake.ChatModel{}
+	cm.Respond = func(context.Context, []*schema.Message) (*schema.Message, error) {
+		if n := len(cm.Calls()); n <= len(errs) && errs[n-1] != nil {
+			return nil, errs[n-1]
+		}
+		return fake.Reply("ok"), nil
+	}
+	return cm
+}
+
+func TestChatModelRetry(t *testing.T) {
+	conf := config.Resilience{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantKind  error // 错误分类，nil 表示不归类
+		wantCalls int
+	}{
+		{name: "一次成功", wantCalls: 1},
+		{name: "429 后成功", errs: []error{statusError(429)}, wantCalls: 2},
+		{name: "5xx 重试用尽", errs: []error{statusError(500), statusError(502), statusError(503)}, wantErr: true, wantKind: errs.ErrModelUnavailable, wantCalls: 3},
+		{name: "400 不重试", errs: []error{statusError(400)}, wantErr: true, wantKind: errs.ErrConfig, wantCalls: 1},
+		{name: "401 不重试", errs: []error{&openai.RequestError{HTTPStatusCode: 401, Err: errors.New("invalid api key")}}, wantErr: true, wantKind: errs.ErrConfig, wantCalls: 1},
+		{name: "其它错误不归类", errs: []error{errors.New("boom")}, wantErr: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := scriptedModel(tt.errs...)
+			cm := WrapChatModel(inner, NewPolicy(conf))
+			_, err := cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, kind := range []error{errs.ErrModelUnavailable, errs.ErrConfig} {
+				if got, want := errors.Is(err, kind), kind == tt.wantKind; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v; err = %v", kind, got, want, err)
+				}
+			}
+			if got := len(inner.Calls()); got != tt.wantCalls {
+				t.Errorf("inner called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	p := NewPolicy(config.Resilience{FailureThreshold: 2, Cooldown: time.Minute})
+	p.breaker.now = func() time.Time { return now }
+
+	inner := scriptedModel(statusError(500), statusError(500), statusError(500))
+	cm := WrapChatModel(inner, p)
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cm.Generate(ctx, input); err == nil {
+			t.Fatalf("call %d: want error", i)
+		}
+	}
+	if _, err := cm.Generate(ctx, input); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, errs.ErrModelUnavailable) {
+		t.Fatalf("熔断后 error = %v, want ErrCircuitOpen", err)
+	}
+	if got := len(inner.Calls()); got != 2 {
+		t.Fatalf("熔断期间不应调用模型，inner called %d times", got)
+	}
+
+	// 冷却后放行试探请求，失败则继续熔断
+	now = now.Add(time.Minute)
+	if _, err := cm.Generate(ctx, input); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("试探请求 error = %v", err)
+	}
+	if _, err := cm.Generate(ctx, input); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("试探失败后 error = %v, want ErrCircuitOpen", err)
+	}
+
+	// 再次冷却后试探成功，恢复正常
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := cm.Generate(ctx, input); err != nil {
+			t.Fatalf("恢复后 call %d error = %v", i, err)
+		}
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	cm := WrapChatModel(scriptedModel(statusError(429)), NewPolicy(config.Resilience{MaxRetries: 3, InitialBackoff: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("退避等待没有响应 ctx 取消")
+	}
+}
+
+func TestEmbedderRateLimit(t *testing.T) {
+	// QPS 20，突发 20：第 21 个请求需要等待约 50ms
+	e := WrapEmbedder(&fake.Embedder{Dims: 8}, NewPolicy(config.Resilience{QPS: 20}))
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 21; i++ {
+		if _, err := e.EmbedStrings(ctx, []string{"text"}); err != nil {
+			t.Fatalf("EmbedStrings() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("21 requests took %s, want throttling", elapsed)
+	}
+}
+
+func TestWithToolsSharesPolicy(t *testing.T) {
+	p := NewPolicy(config.Resilience{FailureThreshold: 1, Cooldown: time.Minute})
+	cm := WrapChatModel(scriptedModel(statusError(500)), p)
+	bound, err := cm.WithTools([]*schema.ToolInfo{{Name: "search"}})
+	if err != nil {
+		t.Fatalf("WithTools() error = %v", err)
+	}
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("hi")}
+	if _, err := bound.Generate(ctx, input); err == nil {
+		t.Fatal("want error")
+	}
+	if _, err := cm.Generate(ctx, input); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("原模型 error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "风寒", want: 2},
+		{text: "abcd", want: 1},
+		{text: "风寒 cold", want: 4},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+)
+
+// ChatModel 在 Policy 保护下调用的对话模型
+type ChatModel struct {
+	model  model.ToolCallingChatModel
+	policy *Policy
+}
+
+// WrapChatModel 为对话模型加上重试、限流与熔断
+func WrapChatModel(m model.ToolCallingChatModel, p *Policy) *ChatModel {
+	return &ChatModel{model: m, policy: p}
+}
+
+// Generate 调用模型，失败时按策略重试，完成后记入输出 token
+func (c *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var out *schema.Message
+	err := c.policy.Do(ctx, estimateMessages(input), func(ctx context.Context) error {
+		var err error
+		out, err = c.model.Generate(ctx, input, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, wrapError("调用对话模型", err)
+	}
+	if out.ResponseMeta != nil && out.ResponseMeta.Usage != nil {
+		c.policy.Charge(out.ResponseMeta.Usage.CompletionTokens)
+	}
+	return out, nil
+}
+
+// Stream 建立流式调用，建立连接失败时按策略重试，流读取过程中的错误直接交给调用方
+func (c *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	var out *schema.StreamReader[*schema.Message]
+	err := c.policy.Do(ctx, estimateMessages(input), func(ctx context.Context) error {
+		var err error
+		out, err = c.model.Stream(ctx, input, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, wrapError("调用对话模型", err)
+	}
+	return out, nil
+}
+
+// WithTools 绑定工具，返回的模型共用同一个 Policy
+func (c *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	m, err := c.model.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatModel{model: m, policy: c.policy}, nil
+}
+
+// GetType 沿用被包装模型的类型，便于回调与链路追踪识别
+func (c *ChatModel) GetType() string {
+	if t, ok := components.GetType(c.model); ok {
+		return t
+	}
+	return ""
+}
+
+// IsCallbacksEnabled 被包装模型自行触发回调时，编排层不再重复触发
+func (c *ChatModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(c.model)
+}
+
+// Embedder 在 Policy 保护下调用的 Embedder
+type Embedder struct {
+	embedder embedding.Embedder
+	policy   *Policy
+}
+
+// WrapEmbedder 为 Embedder 加上重试、限流与熔断
+func WrapEmbedder(e embedding.Embedder, p *Policy) *Embedder {
+	return &Embedder{embedder: e, policy: p}
+}
+
+// EmbedStrings 向量化一批文本，失败时按策略重试整批
+func (e *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	tokens := 0
+	for _, text := range texts {
+		tokens += EstimateTokens(text)
+	}
+
+	var out [][]float64
+	err := e.policy.Do(ctx, tokens, func(ctx context.Context) error {
+		var err error
+		out, err = e.embedder.EmbedStrings(ctx, texts, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, wrapError("调用向量模型", err)
+	}
+	return out, nil
+}
+
+// GetType 沿用被包装 Embedder 的类型
+func (e *Embedder) GetType() string {
+	if t, ok := components.GetType(e.embedder); ok {
+		return t
+	}
+	return ""
+}
+
+// IsCallbacksEnabled 被包装 Embedder 自行触发回调时，编排层不再重复触发
+func (e *Embedder) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(e.embedder)
+}
+
+// wrapError 按失败原因归类：重试用尽与熔断归为 ErrModelUnavailable，
+// 其余 4xx 多为密钥、模型名或请求参数有误，归为 ErrConfig，其它错误原样返回
+func wrapError(op string, err error) error {
+	switch {
+	case errors.Is(err, ErrCircuitOpen) || Retryable(err):
+		return errs.Wrap(errs.ErrModelUnavailable, op, err)
+	case clientError(err):
+		return errs.Wrap(errs.ErrConfig, op, err)
+	default:
+		return err
+	}
+}
+
+// estimateMessages 估算消息列表的 token 数，每条消息另加少量角色与格式开销
+func estimateMessages(msgs []*schema.Message) int {
+	tokens := 0
+	for _, msg := range msgs {
+		tokens += EstimateTokens(msg.Content) + 4
+	}
+	return tokens
+}
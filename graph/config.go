@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+
+	"common/config"
+
+	"graph/subject"
+)
+
+// cfg 当前生效的配置，main 启动时从配置文件、环境变量与命令行参数加载
+var cfg = defaultConfig()
+
+// appConfig graph 的全部配置，学科问答的配置直接展开在顶层
+type appConfig struct {
+	LLM            config.LLM `yaml:"llm"`
+	subject.Config `yaml:",inline"`
+}
+
+// defaultConfig 默认配置
+func defaultConfig() *appConfig {
+	return &appConfig{
+		LLM:    config.DefaultLLM(),
+		Config: *subject.DefaultConfig(),
+	}
+}
+
+// Validate 校验全部配置
+func (c *appConfig) Validate() error {
+	return errors.Join(c.LLM.Validate(), c.Config.Validate())
+}
@@ -2,173 +2,84 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"strings"
-	"time"
 
-	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
 	duckduckgo "github.com/cloudwego/eino-ext/components/tool/duckduckgo/v2"
-	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-)
 
-var (
-	// 千问llm
-	llmKey    = os.Getenv("DASHSCOPE_API_KEY")
-	llmApi    = "https://dashscope.aliyuncs.com/compatible-mode/v1" //千问系列API
-	chatModel = "qwen-plus"                                         // chat模型
+	"common/config"
+	"common/errs"
+	"common/provider"
+
+	"graph/subject"
 )
 
 func main() {
+	config.MustLoad(flag.NewFlagSet("graph", flag.ExitOnError), os.Args[1:], cfg)
+	ctx := context.Background()
+
 	// 学科识别演示
-	SubjectAnswer()
+	errs.Exit(SubjectAnswer(ctx))
 	// 模型流程演示
-	//QuestionAnswer()
+	//errs.Exit(QuestionAnswer(ctx))
 }
 
-func SubjectAnswer() {
-	ctx := context.Background()
-
-	// 学科历史上在文
-	type UserState struct {
-		Messages []*schema.Message
-		Subject  string
+// SubjectAnswer 识别问题学科并路由到对应学科节点，由模型作答，同一会话的提问共享历史
+func SubjectAnswer(ctx context.Context) error {
+	chatModel, err := provider.NewChatModel(ctx, &cfg.LLM)
+	if err != nil {
+		return errs.Wrap(errs.ErrModelUnavailable, "创建对话模型", err)
 	}
-
-	type UserParams struct {
-		Subject  string
-		Question string
+	agent, err := subject.New(ctx, chatModel, &cfg.Config)
+	if err != nil {
+		return err
 	}
 
-	graph := compose.NewGraph[*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *UserState {
-		return &UserState{Messages: make([]*schema.Message, 0)}
-	}))
-	questionToHistory := func(ctx context.Context, out UserParams, state *UserState) (UserParams, error) {
-		if state.Subject != out.Subject { // 如果当前对话不是旧对话的学科，重置上下文
-			state.Subject = out.Subject
-			state.Messages = make([]*schema.Message, 0)
+	// 同一会话中的追问沿用上一题的学科与历史
+	ctx = subject.WithSessionID(ctx, cfg.Session.ID)
+	questions := []string{
+		"一个矩形的长是宽的2倍，周长是30厘米，求长和宽分别是多少？",
+		"如果周长变成36厘米，长和宽又是多少？",
+		"把“大模型让向量数据库变得更加重要”翻译成英文",
+	}
+	for _, question := range questions {
+		fmt.Println("问题：", question)
+		if err := streamAnswer(ctx, agent, question); err != nil {
+			return err
 		}
-		state.Messages = append(state.Messages, &schema.Message{Role: schema.User, Content: out.Question})
-		return out, nil
 	}
+	return nil
+}
 
-	msgToHistory := func(ctx context.Context, out *schema.Message, state *UserState) (*schema.Message, error) {
-		state.Messages = append(state.Messages, out)
-		return out, nil
+// streamAnswer 流式运行 Graph，回答逐段打印
+func streamAnswer(ctx context.Context, agent *subject.SessionGraph, question string) error {
+	sr, err := agent.Stream(ctx, schema.UserMessage(question))
+	if err != nil {
+		return fmt.Errorf("运行 Graph 失败: %w", err)
 	}
-
-	// 学科识别：根据输入内容判断学科，输出到 UserParams 结构
-	subjectIdentify := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (UserParams, error) {
-		out := UserParams{Subject: "other", Question: input.Content}
-		if strings.Contains(input.Content, "数学") {
-			out.Subject = "math"
-			return out, nil
+	defer sr.Close()
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			fmt.Println()
+			return nil
 		}
-		if strings.Contains(input.Content, "英文") || strings.Contains(strings.ToLower(input.Content), "english") {
-			out.Subject = "english"
-			return out, nil
+		if err != nil {
+			return fmt.Errorf("运行 Graph 失败: %w", err)
 		}
-		return out, nil
-	})
-
-	branch := compose.NewGraphBranch(func(ctx context.Context, in UserParams) (endNode string, err error) {
-		switch in.Subject {
-		case "math":
-			return "mathNode", nil
-		case "english":
-			return "englishNode", nil
-		default:
-			return "otherNode", nil
-		}
-	}, map[string]bool{"mathNode": true, "englishNode": true, "otherNode": true})
-
-	// 数学节点：使用 compose.ProcessState 读取 TopicState
-	mathNode := compose.InvokableLambda(func(ctx context.Context, in UserParams) (*schema.Message, error) {
-		subject := in.Subject
-		_ = compose.ProcessState(ctx, func(_ context.Context, st *UserState) error {
-			if st != nil && st.Subject != "" {
-				subject = st.Subject
-			}
-			return nil
-		})
-		result := "数学题解答（学科：" + subject + ")"
-		return &schema.Message{Role: schema.Assistant, Content: result}, nil
-	})
-
-	// 英语节点：演示翻译（此处为示例 stub，可接入 LLM 或翻译 API）
-	englishNode := compose.InvokableLambda(func(ctx context.Context, in UserParams) (*schema.Message, error) {
-		translated := "翻译结果（示例）"
-		return &schema.Message{Role: schema.Assistant, Content: translated}, nil
-	})
-
-	// 其它节点：返回无法解答
-	otherNode := compose.InvokableLambda(func(ctx context.Context, in UserParams) (*schema.Message, error) {
-		return &schema.Message{Role: schema.Assistant, Content: "抱歉，该问题暂时无法解答"}, nil
-	})
-
-	graph.AddLambdaNode("subjectIdentify", subjectIdentify,
-		compose.WithStatePostHandler(questionToHistory),
-	)
-	graph.AddLambdaNode("mthNode", mathNode, compose.WithStatePostHandler(msgToHistory))
-	graph.AddLambdaNode("englishNode", englishNode, compose.WithStatePostHandler(msgToHistory))
-	graph.AddLambdaNode("otherNode", otherNode)
-
-	graph.AddEdge(compose.START, "subjectIdentify")
-	graph.AddBranch("subjectIdentify", branch)
-	graph.AddEdge("mathNode", compose.END)
-	graph.AddEdge("englishNode", compose.END)
-	graph.AddEdge("otherNode", compose.END)
-	agent, err := graph.Compile(ctx)
-	if err != nil {
-		panic(err)
-	}
-	input := &schema.Message{
-		Role:    schema.User,
-		Content: "请解答数学题:一个矩形的长是宽的2倍，周长是30厘米，求长和宽分别是多少？",
+		fmt.Print(chunk.Content)
 	}
-	output, err := agent.Invoke(ctx, input, compose.WithCallbacks(genCallback()))
-	if err != nil {
-		panic(err)
-	}
-
-	fmt.Println(output.Content)
-}
-
-func genCallback() callbacks.Handler {
-	startKey := "node_start_time"
-	handler := callbacks.NewHandlerBuilder().OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
-		// 记录开始时间
-		fmt.Printf("当前%s节点输入:%s\n", info.Component, input)
-		return context.WithValue(ctx, startKey, time.Now())
-	}).OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
-		// 计算耗时
-		if v := ctx.Value(startKey); v != nil {
-			if t, ok := v.(time.Time); ok {
-				cost := time.Since(t)
-				fmt.Printf("当前%s节点耗时:%s\n", info.Component, cost)
-			}
-		}
-		fmt.Println(info, output)
-
-		fmt.Printf("当前%s节点输出:%s\n", info.Component, output)
-		return ctx
-	}).OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
-		fmt.Println(info, output)
-		return ctx
-	}).Build()
-	return handler
 }
 
-func QuestionAnswer() {
-	ctx := context.Background()
-
-	if llmKey == "" {
-		log.Fatal("DASHSCOPE_API_KEY 未设置，请在环境变量中配置后再运行")
-	}
+// QuestionAnswer 搜索题目后让模型基于搜索结果解题
+func QuestionAnswer(ctx context.Context) error {
 
 	// 读取用户题目：命令行参数或交互输入
 	question := "一个矩形的长是宽的2倍，周长是30厘米，求长和宽分别是多少？"
@@ -179,31 +90,42 @@ func QuestionAnswer() {
 		Region:     duckduckgo.RegionUS, // Use US region to avoid 202 issues
 	})
 	if err != nil {
-		log.Fatalf("NewTool of duckduckgo failed, err=%v", err)
+		return errs.Wrap(errs.ErrToolCall, "创建 duckduckgo 搜索工具", err)
+	}
+
+	// Create chat model
+	chatModel, err := provider.NewChatModel(ctx, &cfg.LLM)
+	if err != nil {
+		return errs.Wrap(errs.ErrModelUnavailable, "创建对话模型", err)
+	}
+
+	finalMsg, err := answerQuestion(ctx, textSearchTool, chatModel, question)
+	if err != nil {
+		return err
 	}
+	fmt.Println("解题过程和答案：")
+	fmt.Print(finalMsg.Content)
+	return nil
+}
 
-	// Create tool info
-	toolInfo, err := textSearchTool.Info(ctx)
+// answerQuestion 先用搜索工具检索题目，再让模型仅基于搜索内容解题
+func answerQuestion(ctx context.Context, searchTool tool.BaseTool, chatModel model.ToolCallingChatModel, question string) (*schema.Message, error) {
+	toolInfo, err := searchTool.Info(ctx)
 	if err != nil {
-		log.Fatalf("Info of duckduckgo failed, err=%v", err)
+		return nil, errs.Wrap(errs.ErrToolCall, "获取工具信息", err)
 	}
 
-	// Create tools node
 	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
-		Tools: []tool.BaseTool{
-			textSearchTool,
-		},
+		Tools: []tool.BaseTool{searchTool},
 	})
 	if err != nil {
-		log.Fatalf("Failed to create tools node: %v", err)
+		return nil, errs.Wrap(errs.ErrToolCall, "创建工具节点", err)
 	}
 
-	// Create chat model
-	chatModel, err := createChatModel(ctx)
+	chatModel, err = chatModel.WithTools([]*schema.ToolInfo{toolInfo})
 	if err != nil {
-		log.Fatalf("Failed to create chat model: %v", err)
+		return nil, fmt.Errorf("绑定工具失败: %w", err)
 	}
-	chatModel.BindTools([]*schema.ToolInfo{toolInfo})
 
 	// 使用一个 Graph：START → tools → build_messages(lambda) → chat_model → END
 	graph := compose.NewGraph[*schema.Message, *schema.Message]()
@@ -243,7 +165,7 @@ func QuestionAnswer() {
 	// 编译 Graph 得到 agent
 	agent, err := graph.Compile(ctx, compose.WithMaxRunSteps(10))
 	if err != nil {
-		log.Fatalf("Failed to compile graph: %v", err)
+		return nil, fmt.Errorf("编译 Graph 失败: %w", err)
 	}
 
 	// 构造 assistant.tool_calls，直接触发搜索
@@ -260,23 +182,10 @@ func QuestionAnswer() {
 		},
 	}
 
-	finalMsg, err := agent.Invoke(ctx, toolCallMsg)
+	// 流程中只有搜索工具与模型两处会失败，模型不可用时由 ExitCode 优先归类
+	out, err := agent.Invoke(ctx, toolCallMsg)
 	if err != nil {
-		fmt.Println("流程失败：", err)
-		return
+		return nil, errs.Wrap(errs.ErrToolCall, "搜索解题", err)
 	}
-	fmt.Println("解题过程和答案：")
-	fmt.Print(finalMsg.Content)
-}
-
-// createChatModel 创建对话模型
-func createChatModel(ctx context.Context) (*chatOpenAi.ChatModel, error) {
-	// 创建 LLM
-	llm, err := chatOpenAi.NewChatModel(ctx, &chatOpenAi.ChatModelConfig{
-		APIKey:  llmKey,
-		Model:   chatModel,
-		Timeout: 60 * time.Second, // 添加超时
-		BaseURL: llmApi,
-	})
-	return llm, err
+	return out, nil
 }
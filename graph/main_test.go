@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"common/config"
+	"common/errs"
+	"common/fake"
+)
+
+// searchStub 返回固定结果的搜索工具，并记录收到的参数
+type searchStub struct {
+	result string
+	err    error
+	args   []string
+}
+
+func (s *searchStub) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "search", Desc: "搜索"}, nil
+}
+
+func (s *searchStub) InvokableRun(_ context.Context, args string, _ ...tool.Option) (string, error) {
+	s.args = append(s.args, args)
+	return s.result, s.err
+}
+
+func TestAnswerQuestion(t *testing.T) {
+	search := &searchStub{result: "长 10 厘米，宽 5 厘米 https://example.com"}
+	cm := fake.NewChatModel(fake.Reply("设宽为 x，则 2(x+2x)=30，x=5。来源：https://example.com"))
+
+	question := "一个矩形的长是宽的2倍，周长是30厘米，求长和宽分别是多少？"
+	msg, err := answerQuestion(context.Background(), search, cm, question)
+	if err != nil {
+		t.Fatalf("answerQuestion() error = %v", err)
+	}
+
+	if !strings.Contains(msg.Content, "x=5") {
+		t.Errorf("answer = %q", msg.Content)
+	}
+	if len(search.args) != 1 || !strings.Contains(search.args[0], question) {
+		t.Errorf("search args = %q", search.args)
+	}
+	// 模型只应收到系统提示与拼接了搜索内容的题目
+	input := cm.LastInput()
+	if len(input) != 2 || !strings.Contains(input[1].Content, search.result) || !strings.Contains(input[1].Content, question) {
+		t.Errorf("model input = %v", input)
+	}
+	if calls := cm.Calls(); len(calls) != 1 || len(calls[0].Tools) != 1 || calls[0].Tools[0].Name != "search" {
+		t.Errorf("search tool not bound to model: %+v", calls)
+	}
+}
+
+func TestAnswerQuestionToolError(t *testing.T) {
+	search := &searchStub{err: errors.New("202 Ratelimit")}
+	cm := fake.NewChatModel()
+
+	_, err := answerQuestion(context.Background(), search, cm, "1+1 等于几？")
+	if !errors.Is(err, errs.ErrToolCall) {
+		t.Fatalf("answerQuestion() error = %v, want ErrToolCall", err)
+	}
+	if len(cm.Calls()) != 0 {
+		t.Errorf("model called after tool failure: %d", len(cm.Calls()))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := defaultConfig()
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	if err := config.Load(fs, []string{"-llm.api_key", "sk-test", "-session.id", "s1"}, c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// 学科问答的配置展开在顶层，不带 config 前缀
+	if c.Session.ID != "s1" || fs.Lookup("config.session.id") != nil {
+		t.Errorf("session.id = %q", c.Session.ID)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	c.LLM.Provider = "unknown"
+	c.Session.Store = "redis"
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "session.store") || !strings.Contains(err.Error(), "llm.provider") {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
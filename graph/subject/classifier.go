@@ -0,0 +1,149 @@
+package subject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 学科识别结果的来源
+const (
+	sourceModel   = "model"
+	sourceKeyword = "keyword"
+)
+
+// classifyHistoryMessages 交给分类器的最近对话条数，每条最多 classifyHistoryRunes 个字符
+const (
+	classifyHistoryMessages = 4
+	classifyHistoryRunes    = 200
+)
+
+// jsonObjectPattern 提取模型输出中的 JSON 对象，兼容 ```json 代码块
+var jsonObjectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+// Classification 一次学科识别的结果
+type Classification struct {
+	Subject    string  `json:"subject"`
+	Confidence float64 `json:"confidence"` // 0-1，关键词识别时为 0
+	Reason     string  `json:"reason,omitempty"`
+	Source     string  `json:"source"` // model 或 keyword
+}
+
+// subjectClassifier 让模型结合上一题的学科与最近的对话，从分类体系中选出学科并给出置信度
+// 模型调用失败、输出无法解析、学科不在体系内或置信度低于阈值时，退回关键词识别；
+// 关键词也不命中时沿用上一题的学科，新会话才使用 fallback 学科
+type subjectClassifier struct {
+	registry      *Registry
+	minConfidence float64
+	classify      compose.Runnable[map[string]any, *Classification]
+}
+
+// newSubjectClassifier 创建学科分类器，分类体系为 registry 中的全部学科
+func newSubjectClassifier(ctx context.Context, chatModel model.BaseChatModel, registry *Registry, minConfidence float64) (*subjectClassifier, error) {
+	tmpl := prompt.FromMessages(schema.FString,
+		schema.SystemMessage("你是题目分类器，判断用户的问题属于哪个学科。可选学科：\n{subjects}\n"+
+			`只输出 JSON 对象，例如 {{"subject": "math", "confidence": 0.9, "reason": "一句话理由"}}，`+
+			"subject 必须是可选学科的名称之一，confidence 为 0 到 1 的置信度，不要输出其它内容。{history}"),
+		schema.UserMessage("{question}"),
+	)
+
+	chain := compose.NewChain[map[string]any, *Classification]()
+	chain.
+		AppendChatTemplate(tmpl, compose.WithNodeName("classify_prompt")).
+		AppendChatModel(chatModel, compose.WithNodeName("classify_model")).
+		AppendLambda(compose.InvokableLambda(func(_ context.Context, msg *schema.Message) (*Classification, error) {
+			var c Classification
+			if err := parseJSONObject(msg.Content, &c); err != nil {
+				return nil, err
+			}
+			c.Source = sourceModel
+			return &c, nil
+		}), compose.WithNodeName("parse_classification"))
+
+	runnable, err := chain.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译学科识别 Chain 失败: %w", err)
+	}
+	return &subjectClassifier{registry: registry, minConfidence: minConfidence, classify: runnable}, nil
+}
+
+// Classify 识别问题所属学科，总是返回结果；退回关键词识别时 Reason 记录原因
+// previous 为会话中上一题的学科，history 为会话的对话历史，新会话时均为空
+func (c *subjectClassifier) Classify(ctx context.Context, question, previous string, history []*schema.Message) *Classification {
+	result, err := c.classify.Invoke(ctx, map[string]any{
+		"subjects": c.registry.Describe(),
+		"history":  describeHistory(previous, history),
+		"question": question,
+	})
+	switch {
+	case err != nil:
+		return c.fallback(question, previous, fmt.Sprintf("模型识别失败: %v", err))
+	case !c.known(result.Subject):
+		return c.fallback(question, previous, fmt.Sprintf("模型给出的学科 %q 不在分类体系中", result.Subject))
+	case result.Confidence < c.minConfidence:
+		return c.fallback(question, previous, fmt.Sprintf("模型识别为 %s，置信度 %.2f 低于 %.2f", result.Subject, result.Confidence, c.minConfidence))
+	}
+	return result
+}
+
+// fallback 按关键词识别，都不命中时沿用上一题的学科，没有上一题时使用 fallback 学科
+func (c *subjectClassifier) fallback(question, previous, reason string) *Classification {
+	subject, ok := c.registry.matchKeyword(question)
+	switch {
+	case ok:
+	case c.known(previous):
+		subject, reason = previous, reason+"；关键词未命中，沿用上一题的学科"
+	default:
+		subject = c.registry.fallback.Name
+	}
+	return &Classification{Subject: subject, Reason: reason, Source: sourceKeyword}
+}
+
+// describeHistory 把上一题的学科与最近的对话写成分类器提示词的补充说明，新会话时为空
+func describeHistory(previous string, history []*schema.Message) string {
+	if previous == "" && len(history) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n用户在同一会话中继续提问。")
+	if previous != "" {
+		sb.WriteString("上一题的学科：" + previous + "。")
+	}
+	if len(history) > 0 {
+		sb.WriteString("最近的对话：\n")
+		for _, msg := range history[max(0, len(history)-classifyHistoryMessages):] {
+			content := []rune(msg.Content)
+			if len(content) > classifyHistoryRunes {
+				content = append(content[:classifyHistoryRunes], '…')
+			}
+			fmt.Fprintf(&sb, "%s: %s\n", msg.Role, string(content))
+		}
+	}
+	sb.WriteString("\n省略了主语或只更换了条件的追问，沿用上一题的学科。")
+	return sb.String()
+}
+
+// known 学科是否在分类体系中
+func (c *subjectClassifier) known(subject string) bool {
+	_, ok := c.registry.Get(subject)
+	return ok
+}
+
+// parseJSONObject 从模型输出中提取并解析 JSON 对象
+func parseJSONObject(content string, v any) error {
+	raw := jsonObjectPattern.FindString(content)
+	if raw == "" {
+		return fmt.Errorf("模型输出不是 JSON 对象: %s", content)
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("解析模型输出失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package subject
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+func TestSubjectClassifier(t *testing.T) {
+	history := []*schema.Message{
+		schema.UserMessage("一个矩形的长是宽的2倍，周长是30厘米，求长和宽"),
+		schema.AssistantMessage("长 10 厘米，宽 5 厘米", nil),
+	}
+	followUp := "如果周长变成36厘米，长和宽又是多少？"
+
+	tests := []struct {
+		name       string
+		question   string
+		previous   string
+		history    []*schema.Message
+		reply      *schema.Message // nil 表示模型调用失败
+		want       string
+		wantSource string
+	}{
+		{name: "模型识别", question: "一个矩形的长是宽的2倍，周长是30厘米，求长和宽", reply: fake.Reply("```json\n{\"subject\": \"math\", \"confidence\": 0.95}\n```"), want: "math", wantSource: sourceModel},
+		{name: "置信度低", question: "把这句话翻译成英文", reply: fake.Reply(`{"subject": "other", "confidence": 0.3}`), want: "english", wantSource: sourceKeyword},
+		{name: "未知学科", question: "请解答数学题：1+1", reply: fake.Reply(`{"subject": "physics", "confidence": 0.9}`), want: "math", wantSource: sourceKeyword},
+		{name: "输出不是 JSON", question: "今天天气怎么样", reply: fake.Reply("这是其它问题"), want: "other", wantSource: sourceKeyword},
+		{name: "模型失败", question: "请解答数学题：1+1", want: "math", wantSource: sourceKeyword},
+		{name: "追问由模型结合历史识别", question: followUp, previous: "math", history: history, reply: fake.Reply(`{"subject": "math", "confidence": 0.9}`), want: "math", wantSource: sourceModel},
+		{name: "追问时模型失败沿用上一题学科", question: followUp, previous: "math", history: history, want: "math", wantSource: sourceKeyword},
+		{name: "追问置信度低沿用上一题学科", question: followUp, previous: "math", history: history, reply: fake.Reply(`{"subject": "other", "confidence": 0.3}`), want: "math", wantSource: sourceKeyword},
+		{name: "关键词优先于上一题学科", question: "把这句话翻译成英文", previous: "math", history: history, want: "english", wantSource: sourceKeyword},
+		{name: "上一题学科不在分类体系中", question: followUp, previous: "physics", want: "other", wantSource: sourceKeyword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := fake.NewChatModel()
+			if tt.reply != nil {
+				cm.Replies = []*schema.Message{tt.reply}
+			}
+			classifier, err := newSubjectClassifier(ctx, cm, newTestRegistry(t), 0.6)
+			if err != nil {
+				t.Fatalf("newSubjectClassifier() error = %v", err)
+			}
+
+			got := classifier.Classify(ctx, tt.question, tt.previous, tt.history)
+			if got.Subject != tt.want || got.Source != tt.wantSource {
+				t.Errorf("Classify() = %+v, want %s from %s", got, tt.want, tt.wantSource)
+			}
+			if tt.wantSource == sourceKeyword && got.Reason == "" {
+				t.Error("退回关键词识别时应记录原因")
+			}
+			// 提示词中列出分类体系，追问时带上上一题的学科与最近的对话
+			input := cm.LastInput()
+			if !strings.Contains(input[0].Content, "- english：英语") || input[1].Content != tt.question {
+				t.Errorf("model input = %v", input)
+			}
+			if hasHistory := strings.Contains(input[0].Content, "上一题的学科："+tt.previous+"。"); hasHistory != (tt.previous != "") {
+				t.Errorf("system prompt = %q", input[0].Content)
+			}
+			for _, msg := range tt.history {
+				if !strings.Contains(input[0].Content, msg.Content) {
+					t.Errorf("system prompt 缺少历史 %q", msg.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeHistory(t *testing.T) {
+	if got := describeHistory("", nil); got != "" {
+		t.Errorf("新会话 describeHistory() = %q, want empty", got)
+	}
+
+	var history []*schema.Message
+	for i := 0; i < 6; i++ {
+		history = append(history, schema.UserMessage(strings.Repeat(string(rune('a'+i)), classifyHistoryRunes+10)))
+	}
+	got := describeHistory("math", history)
+	if strings.Contains(got, "aaa") || strings.Contains(got, "bbb") || !strings.Contains(got, "fff") {
+		t.Errorf("只应保留最近 %d 条对话: %q", classifyHistoryMessages, got)
+	}
+	if strings.Contains(got, strings.Repeat("f", classifyHistoryRunes+1)) || !strings.Contains(got, "…") {
+		t.Errorf("过长的消息应截断: %q", got)
+	}
+}
+
+func TestSubjectGraphRecordsClassification(t *testing.T) {
+	ctx := context.Background()
+	var recorded *Classification
+	cm := &fake.ChatModel{Respond: func(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+		if input[0].Role == schema.System && strings.Contains(input[0].Content, "分类器") {
+			return fake.Reply(`{"subject": "math", "confidence": 0.8, "reason": "求长和宽是几何应用题"}`), nil
+		}
+		err := compose.ProcessState(ctx, func(_ context.Context, st *UserState) error {
+			recorded = st.Classification
+			return nil
+		})
+		return fake.Reply("长 10 厘米，宽 5 厘米"), err
+	}}
+	registry := newTestRegistry(t)
+	agent, err := NewGraph(ctx, &GraphConfig{ChatModel: cm, Registry: registry, MinConfidence: 0.6})
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+
+	// 问题中没有“数学”二字，关键词识别会归为 other
+	if _, err := agent.Invoke(ctx, schema.UserMessage("一个矩形的长是宽的2倍，周长是30厘米，求长和宽")); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if recorded == nil || recorded.Subject != "math" || recorded.Source != sourceModel || recorded.Confidence != 0.8 {
+		t.Errorf("UserState.Classification = %+v", recorded)
+	}
+	if math, _ := registry.Get("math"); cm.LastInput()[0].Content != math.Prompt {
+		t.Errorf("未路由到数学节点: %v", cm.LastInput()[0].Content)
+	}
+}
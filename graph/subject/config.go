@@ -0,0 +1,126 @@
+package subject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+)
+
+// Config 学科问答的配置，嵌入调用方的配置结构体时使用 yaml:",inline"
+type Config struct {
+	Classifier  ClassifierConfig  `yaml:"classifier"`
+	Session     SessionConfig     `yaml:"session"`
+	Translation TranslationConfig `yaml:"translation"`
+	// Subjects 学科注册表，只能在配置文件中填写，出现时整体替换默认学科
+	Subjects []Subject `yaml:"subjects"`
+}
+
+// ClassifierConfig 学科识别
+type ClassifierConfig struct {
+	MinConfidence float64 `yaml:"min_confidence" usage:"模型置信度低于该值时改用关键词识别，0-1"`
+	Fallback      string  `yaml:"fallback" usage:"无法归类时使用的学科，须在 subjects 中声明"`
+}
+
+// SessionConfig 会话存储，同一会话的提问共享学科与历史
+type SessionConfig struct {
+	ID    string        `yaml:"id" env:"SESSION_ID" usage:"会话 ID，为空时每次提问使用新的会话"`
+	Store string        `yaml:"store" usage:"会话存储：memory 或 file"`
+	TTL   time.Duration `yaml:"ttl" usage:"会话超过该时长未更新即过期，0 为不过期"`
+	Dir   string        `yaml:"dir" usage:"file 存储的会话目录"`
+}
+
+// TranslationConfig translate 类学科的翻译设置
+type TranslationConfig struct {
+	Glossary []string `yaml:"glossary" usage:"术语表，每项为 中文=English，逗号分隔，翻译时固定使用"`
+}
+
+// DefaultConfig 默认配置：数学、英语与兜底学科，进程内会话存储
+func DefaultConfig() *Config {
+	return &Config{
+		Classifier: ClassifierConfig{MinConfidence: 0.6, Fallback: "other"},
+		Session:    SessionConfig{ID: "demo", Store: "memory", TTL: 30 * time.Minute, Dir: "./sessions"},
+		Translation: TranslationConfig{
+			Glossary: []string{"大模型=large language model", "向量数据库=vector database"},
+		},
+		Subjects: []Subject{
+			{
+				Name:        "math",
+				Description: "数学：计算、方程、几何、应用题等",
+				Keywords:    []string{"数学"},
+				Examples:    []string{"一个矩形的长是宽的2倍，周长是30厘米，求长和宽"},
+				Prompt:      "你是耐心的数学老师。分步骤解题，写出关键的列式与计算过程，最后单独一行给出答案。",
+			},
+			{
+				Name:        "english",
+				Description: "英语：中英互译，以及译文中的语法与用词",
+				Keywords:    []string{"英文", "english", "翻译"},
+				Examples:    []string{"把“我喜欢读书”翻译成英文", "What does “break a leg” mean in Chinese?"},
+				Prompt:      "你是中英翻译老师。译文准确、自然，符合目标语言的表达习惯。",
+				Kind:        KindTranslate,
+			},
+			{
+				Name:        "other",
+				Description: "其它：不属于以上学科的问题",
+				Prompt:      "你是学习助手。问题不属于数学或英语时，简要作答；无法回答的问题请如实说明。",
+			},
+		},
+	}
+}
+
+// Validate 校验学科、学科识别、会话与翻译配置
+func (c *Config) Validate() error {
+	var errs []error
+	if _, err := NewRegistry(c.Subjects, c.Classifier.Fallback); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Classifier.MinConfidence < 0 || c.Classifier.MinConfidence > 1 {
+		errs = append(errs, errors.New("classifier.min_confidence 必须在 0 到 1 之间"))
+	}
+	switch {
+	case c.Session.Store != "memory" && c.Session.Store != "file":
+		errs = append(errs, fmt.Errorf("未知的 session.store %q，可选: memory、file", c.Session.Store))
+	case c.Session.Store == "file" && c.Session.Dir == "":
+		errs = append(errs, errors.New("session.store 为 file 时须配置 session.dir"))
+	}
+	if c.Session.TTL < 0 {
+		errs = append(errs, errors.New("session.ttl 不能小于 0"))
+	}
+	if _, err := parseGlossary(c.Translation.Glossary); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// New 按配置组装学科 Graph 与会话存储，返回的 Graph 按 context 中的会话 ID 载入与保存状态，见 WithSessionID
+func New(ctx context.Context, chatModel model.ToolCallingChatModel, c *Config) (*SessionGraph, error) {
+	registry, err := NewRegistry(c.Subjects, c.Classifier.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := NewTools(ctx, c.Subjects)
+	if err != nil {
+		return nil, err
+	}
+	translator, err := NewTranslator(c.Translation.Glossary)
+	if err != nil {
+		return nil, err
+	}
+	runnable, err := NewGraph(ctx, &GraphConfig{
+		ChatModel:     chatModel,
+		Registry:      registry,
+		Tools:         tools,
+		Translator:    translator,
+		MinConfidence: c.Classifier.MinConfidence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewSessionStore(c.Session)
+	if err != nil {
+		return nil, err
+	}
+	return NewSessionGraph(runnable, store), nil
+}
@@ -0,0 +1,168 @@
+package subject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	duckduckgo "github.com/cloudwego/eino-ext/components/tool/duckduckgo/v2"
+	"github.com/cloudwego/eino/components/tool"
+
+	"common/errs"
+)
+
+// Subject 一个学科的声明，在配置文件的 subjects 列表中填写
+type Subject struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"` // 交给分类器的学科说明
+	Keywords    []string `yaml:"keywords"`    // 模型识别不可用时按关键词识别，不区分大小写
+	Examples    []string `yaml:"examples"`    // 交给分类器的示例问题
+	Prompt      string   `yaml:"prompt"`      // 学科节点的系统提示词
+	Tools       []string `yaml:"tools"`       // 学科节点可调用的工具，见 toolFactories
+	Kind        string   `yaml:"kind"`        // 节点类型：为空时按提示词作答，translate 为中英互译
+}
+
+// node 学科对应的节点名
+func (s *Subject) node() string {
+	return s.Name + "Node"
+}
+
+// toolFactories 学科可以声明的工具
+var toolFactories = map[string]func(ctx context.Context) (tool.BaseTool, error){
+	"web_search": func(ctx context.Context) (tool.BaseTool, error) {
+		return duckduckgo.NewTextSearchTool(ctx, &duckduckgo.Config{MaxResults: 3, Region: duckduckgo.RegionUS})
+	},
+}
+
+// Registry 已声明的学科，Graph 的学科节点、分支与分类体系都由它生成
+type Registry struct {
+	subjects []Subject
+	fallback *Subject // 无法归类时使用的学科
+}
+
+// NewRegistry 校验学科声明并创建注册表，fallback 为无法归类时使用的学科名
+func NewRegistry(subjects []Subject, fallback string) (*Registry, error) {
+	if len(subjects) == 0 {
+		return nil, errors.New("未配置 subjects")
+	}
+	r := &Registry{subjects: subjects}
+	seen := make(map[string]bool, len(subjects))
+	var errList []error
+	for i := range subjects {
+		s := &subjects[i]
+		switch {
+		case s.Name == "":
+			errList = append(errList, fmt.Errorf("subjects 第 %d 项缺少 name", i+1))
+			continue
+		case seen[s.Name]:
+			errList = append(errList, fmt.Errorf("subjects 中学科 %s 重复", s.Name))
+		case s.Prompt == "":
+			errList = append(errList, fmt.Errorf("学科 %s 缺少 prompt", s.Name))
+		case s.Kind != KindAnswer && s.Kind != KindTranslate:
+			errList = append(errList, fmt.Errorf("学科 %s 的 kind %q 无效，可选: translate", s.Name, s.Kind))
+		}
+		seen[s.Name] = true
+		for _, name := range s.Tools {
+			if _, ok := toolFactories[name]; !ok {
+				errList = append(errList, fmt.Errorf("学科 %s 的工具 %s 不存在", s.Name, name))
+			}
+		}
+		if s.Name == fallback {
+			r.fallback = s
+		}
+	}
+	if r.fallback == nil {
+		errList = append(errList, fmt.Errorf("classifier.fallback 学科 %q 不在 subjects 中", fallback))
+	}
+	if err := errors.Join(errList...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get 按名称查找学科
+func (r *Registry) Get(name string) (*Subject, bool) {
+	for i := range r.subjects {
+		if r.subjects[i].Name == name {
+			return &r.subjects[i], true
+		}
+	}
+	return nil, false
+}
+
+// Match 按关键词识别学科，按声明顺序取第一个命中的学科，都不命中时返回 fallback
+func (r *Registry) Match(content string) string {
+	if name, ok := r.matchKeyword(content); ok {
+		return name
+	}
+	return r.fallback.Name
+}
+
+// matchKeyword 按关键词识别学科，都不命中时返回 false
+func (r *Registry) matchKeyword(content string) (string, bool) {
+	content = strings.ToLower(content)
+	for _, s := range r.subjects {
+		for _, kw := range s.Keywords {
+			if kw != "" && strings.Contains(content, strings.ToLower(kw)) {
+				return s.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Route 学科对应的节点，未注册的学科路由到 fallback
+func (r *Registry) Route(subject string) string {
+	if s, ok := r.Get(subject); ok {
+		return s.node()
+	}
+	return r.fallback.node()
+}
+
+// EndNodes 分支的全部目标节点
+func (r *Registry) EndNodes() map[string]bool {
+	nodes := make(map[string]bool, len(r.subjects))
+	for _, s := range r.subjects {
+		nodes[s.node()] = true
+	}
+	return nodes
+}
+
+// Describe 把分类体系写成分类器提示词中的列表
+func (r *Registry) Describe() string {
+	var sb strings.Builder
+	for _, s := range r.subjects {
+		sb.WriteString("- " + s.Name)
+		if s.Description != "" {
+			sb.WriteString("：" + s.Description)
+		}
+		if len(s.Examples) > 0 {
+			sb.WriteString("。例如：" + strings.Join(s.Examples, "；"))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// NewTools 创建学科声明中用到的全部工具
+func NewTools(ctx context.Context, subjects []Subject) (map[string]tool.BaseTool, error) {
+	tools := make(map[string]tool.BaseTool)
+	for _, s := range subjects {
+		for _, name := range s.Tools {
+			if _, ok := tools[name]; ok {
+				continue
+			}
+			factory, ok := toolFactories[name]
+			if !ok {
+				return nil, fmt.Errorf("学科 %s 的工具 %s 不存在", s.Name, name)
+			}
+			t, err := factory(ctx)
+			if err != nil {
+				return nil, errs.Wrap(errs.ErrToolCall, "创建工具 "+name, err)
+			}
+			tools[name] = t
+		}
+	}
+	return tools, nil
+}
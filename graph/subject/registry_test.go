@@ -0,0 +1,200 @@
+package subject
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"common/config"
+	"common/fake"
+)
+
+// searchStub 返回固定结果的搜索工具，并记录收到的参数
+type searchStub struct {
+	result string
+	args   []string
+}
+
+func (s *searchStub) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "search", Desc: "搜索"}, nil
+}
+
+func (s *searchStub) InvokableRun(_ context.Context, args string, _ ...tool.Option) (string, error) {
+	s.args = append(s.args, args)
+	return s.result, nil
+}
+
+// newTestRegistry 使用默认学科创建注册表
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	c := DefaultConfig()
+	registry, err := NewRegistry(c.Subjects, c.Classifier.Fallback)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return registry
+}
+
+func TestSubjectRegistryMatch(t *testing.T) {
+	registry := newTestRegistry(t)
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "请解答数学题：1+1 等于几？", want: "math"},
+		{content: "把这句话翻译成英文", want: "english"},
+		{content: "How do you say hello in English?", want: "english"},
+		{content: "今天天气怎么样", want: "other"},
+		{content: "", want: "other"},
+	}
+	for _, tt := range tests {
+		if got := registry.Match(tt.content); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+	if got := registry.Route("physics"); got != "otherNode" {
+		t.Errorf("Route(未注册学科) = %q, want otherNode", got)
+	}
+}
+
+func TestNewSubjectRegistryErrors(t *testing.T) {
+	valid := Subject{Name: "math", Prompt: "数学老师"}
+	tests := []struct {
+		name     string
+		subjects []Subject
+		fallback string
+		wantErr  string
+	}{
+		{name: "没有学科", fallback: "math", wantErr: "未配置 subjects"},
+		{name: "缺少名称", subjects: []Subject{valid, {Prompt: "老师"}}, fallback: "math", wantErr: "缺少 name"},
+		{name: "学科重复", subjects: []Subject{valid, valid}, fallback: "math", wantErr: "重复"},
+		{name: "缺少提示词", subjects: []Subject{{Name: "math"}}, fallback: "math", wantErr: "缺少 prompt"},
+		{name: "未知工具", subjects: []Subject{{Name: "math", Prompt: "数学老师", Tools: []string{"calculator"}}}, fallback: "math", wantErr: "calculator 不存在"},
+		{name: "兜底学科不存在", subjects: []Subject{valid}, fallback: "other", wantErr: "classifier.fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.subjects, tt.fallback)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewRegistry() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSubjectsFromConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+classifier:
+  fallback: general
+subjects:
+  - name: physics
+    description: 物理
+    keywords: [物理, 速度]
+    prompt: 你是物理老师。
+    tools: [web_search]
+  - name: general
+    prompt: 你是学习助手。
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfig()
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	if err := config.Load(fs, []string{"-config", path}, c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if fs.Lookup("subjects") != nil {
+		t.Error("结构体列表不应注册命令行参数")
+	}
+
+	// 文件中的学科整体替换默认学科
+	if len(c.Subjects) != 2 {
+		t.Fatalf("subjects = %+v", c.Subjects)
+	}
+	physics := c.Subjects[0]
+	if physics.Name != "physics" || physics.Prompt != "你是物理老师。" || len(physics.Keywords) != 2 || physics.Tools[0] != "web_search" {
+		t.Errorf("subjects[0] = %+v", physics)
+	}
+	registry, err := NewRegistry(c.Subjects, c.Classifier.Fallback)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	if got := registry.Match("汽车的速度是多少"); got != "physics" {
+		t.Errorf("Match() = %q, want physics", got)
+	}
+	if got := registry.EndNodes(); len(got) != 2 || !got["physicsNode"] || !got["generalNode"] {
+		t.Errorf("EndNodes() = %v", got)
+	}
+}
+
+func TestSubjectGraphWithTools(t *testing.T) {
+	ctx := context.Background()
+	registry, err := NewRegistry([]Subject{
+		{Name: "physics", Description: "物理", Prompt: "你是物理老师。", Tools: []string{"web_search"}},
+		{Name: "other", Prompt: "你是学习助手。"},
+	}, "other")
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	search := &searchStub{result: "光速约为 3×10^8 m/s"}
+	cm := fake.NewChatModel(
+		fake.Reply(`{"subject": "physics", "confidence": 0.9}`),
+		fake.ToolCallReply(fake.ToolCall("search", `{"query": "光速"}`)),
+		fake.Reply("光速约为每秒 30 万公里"),
+	)
+
+	agent, err := NewGraph(ctx, &GraphConfig{
+		ChatModel:     cm,
+		Registry:      registry,
+		Tools:         map[string]tool.BaseTool{"web_search": search},
+		MinConfidence: 0.6,
+	})
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	out, err := agent.Invoke(ctx, schema.UserMessage("光速是多少？"))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if out.Content != "光速约为每秒 30 万公里" {
+		t.Errorf("output = %q", out.Content)
+	}
+	if len(search.args) != 1 {
+		t.Errorf("search args = %q", search.args)
+	}
+
+	calls := cm.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("model calls = %d, want 3", len(calls))
+	}
+	// 学科节点绑定了声明的工具，并使用该学科的提示词
+	answer := calls[1]
+	if len(answer.Tools) != 1 || answer.Tools[0].Name != "search" || answer.Input[0].Content != "你是物理老师。" {
+		t.Errorf("answer call = %+v", answer)
+	}
+	if last := calls[2].Input; last[len(last)-1].Role != schema.Tool {
+		t.Errorf("工具结果未交给模型: %v", last)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := DefaultConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	c.Classifier.MinConfidence = 1.5
+	c.Classifier.Fallback = "general"
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "min_confidence") || !strings.Contains(err.Error(), "classifier.fallback") {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
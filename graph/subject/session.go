@@ -0,0 +1,260 @@
+package subject
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// sessionIDKey 会话 ID 在 context 中的键
+type sessionIDKey struct{}
+
+// sessionStateKey 本次运行载入的 UserState 在 context 中的键，由 Graph 的 GenLocalState 取出
+type sessionStateKey struct{}
+
+// WithSessionID 在 context 中携带会话 ID，同一会话的提问共享 UserState
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// SessionIDFrom 取出 context 中的会话 ID
+func SessionIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(sessionIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// SessionStore 按会话 ID 保存 UserState，会话不存在或已过期时 Load 返回 nil
+type SessionStore interface {
+	Load(ctx context.Context, id string) (*UserState, error)
+	Save(ctx context.Context, id string, state *UserState) error
+}
+
+// sessionRecord 存储中的一个会话
+type sessionRecord struct {
+	State     *UserState `json:"state"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// expired 会话是否已超过 ttl 未更新，ttl <= 0 时永不过期
+func (r *sessionRecord) expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(r.UpdatedAt) > ttl
+}
+
+// NewSessionStore 按配置创建会话存储
+func NewSessionStore(c SessionConfig) (SessionStore, error) {
+	switch c.Store {
+	case "memory":
+		return newMemorySessionStore(c.TTL), nil
+	case "file":
+		return newFileSessionStore(c.Dir, c.TTL)
+	default:
+		return nil, fmt.Errorf("未知的 session.store %q，可选: memory、file", c.Store)
+	}
+}
+
+// memorySessionStore 进程内的会话存储，超过 ttl 未更新的会话在下次访问时清除
+type memorySessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]*sessionRecord
+	now      func() time.Time
+}
+
+// newMemorySessionStore 创建进程内会话存储
+func newMemorySessionStore(ttl time.Duration) *memorySessionStore {
+	return &memorySessionStore{ttl: ttl, sessions: make(map[string]*sessionRecord), now: time.Now}
+}
+
+// Load 返回会话状态的副本
+func (s *memorySessionStore) Load(_ context.Context, id string) (*UserState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if rec.expired(s.ttl, s.now()) {
+		delete(s.sessions, id)
+		return nil, nil
+	}
+	return rec.State.clone(), nil
+}
+
+// Save 保存会话状态的副本，并顺带清除已过期的会话
+func (s *memorySessionStore) Save(_ context.Context, id string, state *UserState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, rec := range s.sessions {
+		if rec.expired(s.ttl, now) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[id] = &sessionRecord{State: state.clone(), UpdatedAt: now}
+	return nil
+}
+
+// fileSessionStore 每个会话一个 JSON 文件，进程重启后会话仍然可用
+type fileSessionStore struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// newFileSessionStore 创建文件会话存储，目录不存在时创建
+func newFileSessionStore(dir string, ttl time.Duration) (*fileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建会话目录失败: %w", err)
+	}
+	return &fileSessionStore{dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+// path 会话文件路径，会话 ID 转义后作为文件名
+func (s *fileSessionStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// Load 读取会话文件，已过期时删除文件
+func (s *fileSessionStore) Load(_ context.Context, id string) (*UserState, error) {
+	b, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取会话失败: %w", err)
+	}
+	var rec sessionRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("解析会话 %s 失败: %w", id, err)
+	}
+	if rec.expired(s.ttl, s.now()) {
+		if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("删除过期会话失败: %w", err)
+		}
+		return nil, nil
+	}
+	return rec.State, nil
+}
+
+// Save 先写临时文件再重命名，避免中断时留下不完整的会话
+func (s *fileSessionStore) Save(_ context.Context, id string, state *UserState) error {
+	b, err := json.MarshalIndent(&sessionRecord{State: state, UpdatedAt: s.now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+	path := s.path(id)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("写入会话失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入会话失败: %w", err)
+	}
+	return nil
+}
+
+// clone 复制状态，历史消息列表独立，消息本身不会被修改因此共享
+func (s *UserState) clone() *UserState {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Messages = append([]*schema.Message(nil), s.Messages...)
+	return &c
+}
+
+// SessionGraph 在 Graph 运行前按 context 中的会话 ID 载入 UserState，运行结束后保存
+// context 中没有会话 ID 时每次运行使用新的 UserState
+type SessionGraph struct {
+	runnable compose.Runnable[*schema.Message, *schema.Message]
+	store    SessionStore
+}
+
+// NewSessionGraph 为 Graph 接入会话存储
+func NewSessionGraph(runnable compose.Runnable[*schema.Message, *schema.Message], store SessionStore) *SessionGraph {
+	return &SessionGraph{runnable: runnable, store: store}
+}
+
+// Invoke 运行 Graph 并保存会话
+func (g *SessionGraph) Invoke(ctx context.Context, input *schema.Message, opts ...compose.Option) (*schema.Message, error) {
+	ctx, id, state, err := g.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := g.runnable.Invoke(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.save(ctx, id, state); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Stream 流式运行 Graph，输出读完后保存会话；调用方提前关闭输出时不保存
+func (g *SessionGraph) Stream(ctx context.Context, input *schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	ctx, id, state, err := g.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sr, err := g.runnable.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return sr, nil
+	}
+
+	out, w := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer sr.Close()
+		defer w.Close()
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				if err := g.save(ctx, id, state); err != nil {
+					w.Send(nil, err)
+				}
+				return
+			}
+			if closed := w.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// load 按会话 ID 载入状态并放入 context，没有会话 ID 时 state 为 nil
+func (g *SessionGraph) load(ctx context.Context) (context.Context, string, *UserState, error) {
+	id, ok := SessionIDFrom(ctx)
+	if !ok {
+		return ctx, "", nil, nil
+	}
+	state, err := g.store.Load(ctx, id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if state == nil {
+		state = newUserState()
+	}
+	return context.WithValue(ctx, sessionStateKey{}, state), id, state, nil
+}
+
+// save 保存本次运行后的状态
+func (g *SessionGraph) save(ctx context.Context, id string, state *UserState) error {
+	if state == nil {
+		return nil
+	}
+	return g.store.Save(ctx, id, state)
+}
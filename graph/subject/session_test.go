@@ -0,0 +1,248 @@
+package subject
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemorySessionStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	if st, err := store.Load(ctx, "u1"); err != nil || st != nil {
+		t.Fatalf("Load(不存在的会话) = %v, %v", st, err)
+	}
+	state := &UserState{Subject: "math", Messages: []*schema.Message{schema.UserMessage("1+1")}}
+	if err := store.Save(ctx, "u1", state); err != nil {
+		t.Fatal(err)
+	}
+	// 保存后再修改原状态不影响存储
+	state.Messages = append(state.Messages, schema.AssistantMessage("2", nil))
+
+	got, err := store.Load(ctx, "u1")
+	if err != nil || got == nil || got.Subject != "math" || len(got.Messages) != 1 {
+		t.Fatalf("Load() = %+v, %v", got, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if st, _ := store.Load(ctx, "u1"); st != nil {
+		t.Errorf("过期会话仍可读取: %+v", st)
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := newFileSessionStore(dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	id := "../user/1"
+	state := &UserState{
+		Subject:        "english",
+		Messages:       []*schema.Message{schema.UserMessage("hello 是什么意思"), schema.AssistantMessage("你好", nil)},
+		Classification: &Classification{Subject: "english", Confidence: 0.9, Source: sourceModel},
+	}
+	if err := store.Save(ctx, id, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("会话文件应写在会话目录内: %v", files)
+	}
+
+	// 新建存储模拟进程重启
+	reopened, err := newFileSessionStore(dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.now = store.now
+	got, err := reopened.Load(ctx, id)
+	if err != nil || got == nil {
+		t.Fatalf("Load() = %v, %v", got, err)
+	}
+	if got.Subject != "english" || len(got.Messages) != 2 || got.Messages[1].Content != "你好" || got.Classification.Confidence != 0.9 {
+		t.Errorf("Load() = %+v", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if st, err := reopened.Load(ctx, id); err != nil || st != nil {
+		t.Errorf("Load(过期会话) = %+v, %v", st, err)
+	}
+	if _, err := os.Stat(files[0]); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("过期会话文件未删除: %v", err)
+	}
+}
+
+// newSessionTestGraph 创建接入会话存储的学科 Graph，模型按问题中的关键词给出学科，并依次回答 回答1、回答2……
+func newSessionTestGraph(t *testing.T, store SessionStore) (*SessionGraph, *fake.ChatModel) {
+	t.Helper()
+	answers := 0
+	cm := &fake.ChatModel{Respond: func(_ context.Context, input []*schema.Message) (*schema.Message, error) {
+		if strings.Contains(input[0].Content, "分类器") {
+			subject := "math"
+			if strings.Contains(input[len(input)-1].Content, "英文") {
+				subject = "english"
+			}
+			return fake.Reply(`{"subject": "` + subject + `", "confidence": 0.9}`), nil
+		}
+		answers++
+		return fake.Reply("回答" + string(rune('0'+answers))), nil
+	}}
+	runnable, err := NewGraph(context.Background(), &GraphConfig{ChatModel: cm, Registry: newTestRegistry(t), MinConfidence: 0.6})
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	return NewSessionGraph(runnable, store), cm
+}
+
+// contents 消息内容列表
+func contents(msgs []*schema.Message) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.Content
+	}
+	return out
+}
+
+func TestSessionGraphKeepsHistory(t *testing.T) {
+	store := newMemorySessionStore(time.Hour)
+	agent, cm := newSessionTestGraph(t, store)
+	ctx := WithSessionID(context.Background(), "u1")
+
+	steps := []struct {
+		question string
+		// want 学科节点收到的历史消息，不含系统提示词
+		want []string
+	}{
+		{question: "长是宽的2倍，周长30厘米，求长和宽", want: []string{"长是宽的2倍，周长30厘米，求长和宽"}},
+		{question: "周长变成36厘米呢", want: []string{"长是宽的2倍，周长30厘米，求长和宽", "回答1", "周长变成36厘米呢"}},
+		// 学科变化时重置历史
+		{question: "把“你好”翻译成英文", want: []string{"把“你好”翻译成英文"}},
+	}
+	for i, step := range steps {
+		if _, err := agent.Invoke(ctx, schema.UserMessage(step.question)); err != nil {
+			t.Fatalf("第 %d 次 Invoke() error = %v", i+1, err)
+		}
+		if got := contents(cm.LastInput()[1:]); strings.Join(got, "|") != strings.Join(step.want, "|") {
+			t.Errorf("第 %d 次历史 = %q, want %q", i+1, got, step.want)
+		}
+	}
+
+	saved, _ := store.Load(ctx, "u1")
+	if saved == nil || saved.Subject != "english" || len(saved.Messages) != 2 || saved.Classification.Subject != "english" {
+		t.Errorf("保存的会话 = %+v", saved)
+	}
+
+	// 其它会话与没有会话 ID 的提问互不影响
+	if _, err := agent.Invoke(WithSessionID(context.Background(), "u2"), schema.UserMessage("1+1 等于几")); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(cm.LastInput()[1:]); len(got) != 1 {
+		t.Errorf("新会话的历史 = %q", got)
+	}
+	if _, err := agent.Invoke(context.Background(), schema.UserMessage("2+2 等于几")); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(cm.LastInput()[1:]); len(got) != 1 {
+		t.Errorf("没有会话 ID 时的历史 = %q", got)
+	}
+	if saved, _ := store.Load(ctx, "u1"); len(saved.Messages) != 2 {
+		t.Errorf("会话 u1 被其它提问修改: %q", contents(saved.Messages))
+	}
+}
+
+func TestSessionGraphFollowUpKeepsSubject(t *testing.T) {
+	store := newMemorySessionStore(time.Hour)
+	var classifyPrompt string
+	cm := &fake.ChatModel{Respond: func(_ context.Context, input []*schema.Message) (*schema.Message, error) {
+		if strings.Contains(input[0].Content, "分类器") {
+			classifyPrompt = input[0].Content
+			// 第一题识别为数学，追问时置信度不足
+			if strings.Contains(input[0].Content, "上一题的学科") {
+				return fake.Reply(`{"subject": "other", "confidence": 0.3}`), nil
+			}
+			return fake.Reply(`{"subject": "math", "confidence": 0.9}`), nil
+		}
+		return fake.Reply("长 10 厘米，宽 5 厘米"), nil
+	}}
+	runnable, err := NewGraph(context.Background(), &GraphConfig{ChatModel: cm, Registry: newTestRegistry(t), MinConfidence: 0.6})
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	agent := NewSessionGraph(runnable, store)
+	ctx := WithSessionID(context.Background(), "u1")
+
+	// 追问中没有“数学”关键词，应沿用上一题的学科并保留历史
+	for _, question := range []string{"一个矩形的长是宽的2倍，周长是30厘米，求长和宽", "如果周长变成36厘米，长和宽又是多少？"} {
+		if _, err := agent.Invoke(ctx, schema.UserMessage(question)); err != nil {
+			t.Fatalf("Invoke(%q) error = %v", question, err)
+		}
+	}
+	if !strings.Contains(classifyPrompt, "上一题的学科：math") || !strings.Contains(classifyPrompt, "长 10 厘米，宽 5 厘米") {
+		t.Errorf("追问时分类器提示词 = %q", classifyPrompt)
+	}
+	saved, _ := store.Load(ctx, "u1")
+	if saved == nil || saved.Subject != "math" || len(saved.Messages) != 4 || saved.Classification.Source != sourceKeyword {
+		t.Errorf("保存的会话 = %+v", saved)
+	}
+}
+
+func TestSessionGraphStream(t *testing.T) {
+	store := newMemorySessionStore(0)
+	agent, _ := newSessionTestGraph(t, store)
+	ctx := WithSessionID(context.Background(), "u1")
+
+	sr, err := agent.Stream(ctx, schema.UserMessage("1+1 等于几"))
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var sb strings.Builder
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		sb.WriteString(chunk.Content)
+	}
+	sr.Close()
+	if sb.String() != "回答1" {
+		t.Errorf("stream output = %q", sb.String())
+	}
+
+	saved, _ := store.Load(ctx, "u1")
+	if saved == nil || strings.Join(contents(saved.Messages), "|") != "1+1 等于几|回答1" {
+		t.Errorf("流式输出读完后保存的会话 = %+v", saved)
+	}
+}
+
+func TestNewSessionStore(t *testing.T) {
+	if _, err := NewSessionStore(SessionConfig{Store: "redis"}); err == nil {
+		t.Error("未知存储应返回错误")
+	}
+	store, err := NewSessionStore(SessionConfig{Store: "file", Dir: filepath.Join(t.TempDir(), "sessions")})
+	if err != nil {
+		t.Fatalf("NewSessionStore() error = %v", err)
+	}
+	if _, ok := store.(*fileSessionStore); !ok {
+		t.Errorf("store = %T", store)
+	}
+}
@@ -0,0 +1,209 @@
+package subject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// UserState 学科与该学科下的对话历史，接入会话存储时跨提问保存，见 SessionGraph
+type UserState struct {
+	Messages       []*schema.Message `json:"messages"`
+	Subject        string            `json:"subject"`
+	Classification *Classification   `json:"classification,omitempty"` // 最近一次学科识别的结果
+}
+
+// newUserState 新会话的状态
+func newUserState() *UserState {
+	return &UserState{Messages: make([]*schema.Message, 0)}
+}
+
+// UserParams 学科识别的结果
+type UserParams struct {
+	Subject        string
+	Question       string
+	Classification *Classification
+}
+
+// GraphConfig 构建学科 Graph 所需的组件
+type GraphConfig struct {
+	ChatModel model.ToolCallingChatModel
+	Registry  *Registry
+	// Tools 学科声明中用到的工具，见 NewTools
+	Tools map[string]tool.BaseTool
+	// Translator translate 类学科使用，为空时不使用术语表
+	Translator    *Translator
+	MinConfidence float64
+}
+
+// NewGraph 构建 学科识别 → 分支 → 学科节点 的 Graph，学科节点、分支与分类体系均由 Registry 生成
+// 每个学科节点把该学科的系统提示词与 UserState 中的历史交给模型，回答再写回历史；
+// 调用方使用 Stream 时学科节点的回答逐段输出
+func NewGraph(ctx context.Context, c *GraphConfig) (compose.Runnable[*schema.Message, *schema.Message], error) {
+	registry := c.Registry
+	classifier, err := newSubjectClassifier(ctx, c.ChatModel, registry, c.MinConfidence)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := compose.NewGraph[*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *UserState {
+		// 由 SessionGraph 载入的会话状态，运行结束后保存
+		if state, ok := ctx.Value(sessionStateKey{}).(*UserState); ok {
+			return state
+		}
+		return newUserState()
+	}))
+	questionToHistory := func(ctx context.Context, out UserParams, state *UserState) (UserParams, error) {
+		if state.Subject != out.Subject { // 如果当前对话不是旧对话的学科，重置上下文
+			state.Subject = out.Subject
+			state.Messages = make([]*schema.Message, 0)
+		}
+		state.Classification = out.Classification
+		state.Messages = append(state.Messages, &schema.Message{Role: schema.User, Content: out.Question})
+		return out, nil
+	}
+
+	// 回答原样向下游输出，读完后拼成一条消息写回历史，流式运行时不必等整段回答生成
+	msgToHistory := func(ctx context.Context, out *schema.StreamReader[*schema.Message], _ *UserState) (*schema.StreamReader[*schema.Message], error) {
+		sr, w := schema.Pipe[*schema.Message](0)
+		go func() {
+			defer out.Close()
+			defer w.Close()
+			var chunks []*schema.Message
+			for {
+				chunk, err := out.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					w.Send(nil, err)
+					return
+				}
+				chunks = append(chunks, chunk)
+				if w.Send(chunk, nil) {
+					return
+				}
+			}
+			msg, err := schema.ConcatMessages(chunks)
+			if err == nil {
+				err = compose.ProcessState(ctx, func(_ context.Context, state *UserState) error {
+					state.Messages = append(state.Messages, msg)
+					return nil
+				})
+			}
+			if err != nil {
+				w.Send(nil, fmt.Errorf("写入对话历史失败: %w", err))
+			}
+		}()
+		return sr, nil
+	}
+
+	// 学科识别：由模型判断学科，输出到 UserParams 结构
+	// 追问时把上一题的学科与历史一并交给分类器，避免省略了关键词的追问被归入其它学科而重置历史
+	subjectIdentify := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (UserParams, error) {
+		var (
+			previous string
+			history  []*schema.Message
+		)
+		err := compose.ProcessState(ctx, func(_ context.Context, state *UserState) error {
+			previous, history = state.Subject, append([]*schema.Message(nil), state.Messages...)
+			return nil
+		})
+		if err != nil {
+			return UserParams{}, err
+		}
+		c := classifier.Classify(ctx, input.Content, previous, history)
+		return UserParams{Subject: c.Subject, Question: input.Content, Classification: c}, nil
+	})
+
+	branch := compose.NewGraphBranch(func(ctx context.Context, in UserParams) (endNode string, err error) {
+		return registry.Route(in.Subject), nil
+	}, registry.EndNodes())
+
+	if err := graph.AddLambdaNode("subjectIdentify", subjectIdentify, compose.WithStatePostHandler(questionToHistory)); err != nil {
+		return nil, err
+	}
+	for i := range registry.subjects {
+		subject := &registry.subjects[i]
+		answer, err := newSubjectChain(ctx, c, subject)
+		if err != nil {
+			return nil, err
+		}
+		if err := graph.AddGraphNode(subject.node(), answer, compose.WithStreamStatePostHandler(msgToHistory), compose.WithNodeName(subject.node())); err != nil {
+			return nil, err
+		}
+		if err := graph.AddEdge(subject.node(), compose.END); err != nil {
+			return nil, err
+		}
+	}
+	if err := graph.AddEdge(compose.START, "subjectIdentify"); err != nil {
+		return nil, err
+	}
+	if err := graph.AddBranch("subjectIdentify", branch); err != nil {
+		return nil, err
+	}
+
+	agent, err := graph.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译 Graph 失败: %w", err)
+	}
+	return agent, nil
+}
+
+// newSubjectChain 学科节点：系统提示词 + 历史消息 → 模型，声明了工具的学科由 ReAct Agent 作答
+// translate 类学科在提示词中补充语言方向、术语与输出格式，见 Translator
+// 作为子图运行，通过 ProcessState 读取外层 Graph 的 UserState
+func newSubjectChain(ctx context.Context, c *GraphConfig, subject *Subject) (*compose.Chain[UserParams, *schema.Message], error) {
+	chatModel := c.ChatModel
+	systemPrompt := func(UserParams) string { return subject.Prompt }
+	if subject.Kind == KindTranslate {
+		tr := c.Translator
+		if tr == nil {
+			tr = &Translator{}
+		}
+		systemPrompt = func(in UserParams) string { return tr.systemPrompt(subject.Prompt, in.Question) }
+	}
+
+	buildMessages := compose.InvokableLambda(func(ctx context.Context, in UserParams) (messages []*schema.Message, err error) {
+		err = compose.ProcessState(ctx, func(_ context.Context, st *UserState) error {
+			messages = make([]*schema.Message, 0, len(st.Messages)+1)
+			messages = append(messages, schema.SystemMessage(systemPrompt(in)))
+			messages = append(messages, st.Messages...)
+			return nil
+		})
+		return messages, err
+	})
+
+	chain := compose.NewChain[UserParams, *schema.Message]()
+	chain.AppendLambda(buildMessages, compose.WithNodeName("build_messages"))
+	if len(subject.Tools) == 0 {
+		chain.AppendChatModel(chatModel, compose.WithNodeName("chat_model"))
+		return chain, nil
+	}
+
+	subjectTools := make([]tool.BaseTool, 0, len(subject.Tools))
+	for _, name := range subject.Tools {
+		t, ok := c.Tools[name]
+		if !ok {
+			return nil, fmt.Errorf("学科 %s 的工具 %s 未创建", subject.Name, name)
+		}
+		subjectTools = append(subjectTools, t)
+	}
+	agent, err := react.NewAgent(ctx, &react.AgentConfig{
+		ToolCallingModel: chatModel,
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: subjectTools},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建学科 %s 的 Agent 失败: %w", subject.Name, err)
+	}
+	agentGraph, opts := agent.ExportGraph()
+	chain.AppendGraph(agentGraph, append(opts, compose.WithNodeName("agent"))...)
+	return chain, nil
+}
@@ -0,0 +1,56 @@
+package subject
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+func TestSubjectGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		subject  string
+	}{
+		{name: "数学", question: "请解答数学题：1+1 等于几？", subject: "math"},
+		{name: "英语", question: "把“你好”翻译成英文", subject: "english"},
+		{name: "其它", question: "今天天气怎么样", subject: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := fake.NewChatModel(
+				fake.Reply(`{"subject": "`+tt.subject+`", "confidence": 0.9}`),
+				fake.Reply(tt.subject+" 的回答"),
+			)
+			registry := newTestRegistry(t)
+			agent, err := NewGraph(ctx, &GraphConfig{ChatModel: cm, Registry: registry, MinConfidence: 0.6})
+			if err != nil {
+				t.Fatalf("NewGraph() error = %v", err)
+			}
+
+			out, err := agent.Invoke(ctx, schema.UserMessage(tt.question))
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if out.Content != tt.subject+" 的回答" {
+				t.Errorf("output = %q", out.Content)
+			}
+
+			input := cm.LastInput()
+			if len(input) != 2 {
+				t.Fatalf("模型输入 %d 条消息，want 系统提示词 + 问题", len(input))
+			}
+			if subject, _ := registry.Get(tt.subject); input[0].Role != schema.System || !strings.HasPrefix(input[0].Content, subject.Prompt) {
+				t.Errorf("系统提示词 = %q, want %s 学科的提示词", input[0].Content, tt.subject)
+			}
+			if input[1].Role != schema.User || input[1].Content != tt.question {
+				t.Errorf("历史消息 = %+v, want 用户问题", input[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package subject
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 学科节点的类型
+const (
+	KindAnswer    = ""          // 按系统提示词作答
+	KindTranslate = "translate" // 中英互译，见 Translator
+)
+
+// quotedPattern 提取问题中引号内的待翻译内容
+var quotedPattern = regexp.MustCompile(`[“"「『‘](.+?)[”"」』’]`)
+
+// englishWordPattern 英文单词
+var englishWordPattern = regexp.MustCompile(`[A-Za-z]+(?:'[A-Za-z]+)?`)
+
+// glossaryEntry 术语表中的一项，翻译时固定使用
+type glossaryEntry struct {
+	Zh string
+	En string
+}
+
+// parseGlossary 解析术语表，每项为 中文=English
+func parseGlossary(items []string) ([]glossaryEntry, error) {
+	entries := make([]glossaryEntry, 0, len(items))
+	for _, item := range items {
+		zh, en, ok := strings.Cut(item, "=")
+		zh, en = strings.TrimSpace(zh), strings.TrimSpace(en)
+		if !ok || zh == "" || en == "" {
+			return nil, fmt.Errorf("translation.glossary 中的 %q 格式应为 中文=English", item)
+		}
+		entries = append(entries, glossaryEntry{Zh: zh, En: en})
+	}
+	return entries, nil
+}
+
+// Translator 中英互译：检测待翻译内容的语言，按术语表翻译，并讲解语法要点
+type Translator struct {
+	glossary []glossaryEntry
+}
+
+// NewTranslator 创建翻译器
+func NewTranslator(glossary []string) (*Translator, error) {
+	entries, err := parseGlossary(glossary)
+	if err != nil {
+		return nil, err
+	}
+	return &Translator{glossary: entries}, nil
+}
+
+// systemPrompt 在学科提示词后补充检测到的语言方向、命中的术语与输出格式
+func (t *Translator) systemPrompt(prompt, question string) string {
+	text := sourceText(question)
+	from, to := "中文", "英文"
+	if detectLanguage(text) == "en" {
+		from, to = "英文", "中文"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	fmt.Fprintf(&sb, "\n检测到待翻译内容为%s，请译为%s；用户明确指定了目标语言时以用户要求为准。", from, to)
+	if terms := t.matchGlossary(text); len(terms) > 0 {
+		sb.WriteString("\n以下术语必须使用固定译法：")
+		for _, e := range terms {
+			fmt.Fprintf(&sb, "\n- %s = %s", e.Zh, e.En)
+		}
+	}
+	sb.WriteString("\n按以下格式输出，不要输出其它内容：\n译文：<译文>\n语法要点：\n- <1 到 3 条，每条一句话，说明译文中的语法或用词>")
+	return sb.String()
+}
+
+// matchGlossary 术语表中出现在待翻译内容里的项，英文不区分大小写
+func (t *Translator) matchGlossary(text string) []glossaryEntry {
+	lower := strings.ToLower(text)
+	var matched []glossaryEntry
+	for _, e := range t.glossary {
+		if strings.Contains(text, e.Zh) || strings.Contains(lower, strings.ToLower(e.En)) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// sourceText 待翻译内容：优先取引号内的文字，没有引号时为整个问题
+func sourceText(question string) string {
+	if m := quotedPattern.FindStringSubmatch(question); m != nil {
+		return m[1]
+	}
+	return question
+}
+
+// detectLanguage 按汉字与英文单词的数量判断语言：zh 或 en
+func detectLanguage(text string) string {
+	han := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			han++
+		}
+	}
+	if words := len(englishWordPattern.FindAllString(text, -1)); words > han {
+		return "en"
+	}
+	return "zh"
+}
@@ -0,0 +1,146 @@
+package subject
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"common/fake"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "我喜欢读书", want: "zh"},
+		{text: "I like reading books", want: "en"},
+		{text: "break a leg", want: "en"},
+		{text: "我用 Go 写代码", want: "zh"},
+		{text: "", want: "zh"},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSourceText(t *testing.T) {
+	tests := []struct {
+		question string
+		want     string
+	}{
+		{question: "把“我喜欢读书”翻译成英文", want: "我喜欢读书"},
+		{question: `请翻译 "It's raining cats and dogs" 这句话`, want: "It's raining cats and dogs"},
+		{question: "How are you", want: "How are you"},
+	}
+	for _, tt := range tests {
+		if got := sourceText(tt.question); got != tt.want {
+			t.Errorf("sourceText(%q) = %q, want %q", tt.question, got, tt.want)
+		}
+	}
+}
+
+func TestParseGlossary(t *testing.T) {
+	entries, err := parseGlossary([]string{" 大模型 = large language model "})
+	if err != nil || len(entries) != 1 || entries[0] != (glossaryEntry{Zh: "大模型", En: "large language model"}) {
+		t.Fatalf("parseGlossary() = %+v, %v", entries, err)
+	}
+	for _, item := range []string{"大模型", "=LLM", "大模型="} {
+		if _, err := parseGlossary([]string{item}); err == nil {
+			t.Errorf("parseGlossary(%q) 应返回错误", item)
+		}
+	}
+}
+
+func TestTranslatorSystemPrompt(t *testing.T) {
+	tr, err := NewTranslator([]string{"大模型=large language model", "向量数据库=vector database"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zh := tr.systemPrompt("翻译老师", "把“大模型很强大”翻译成英文")
+	if !strings.HasPrefix(zh, "翻译老师") || !strings.Contains(zh, "待翻译内容为中文，请译为英文") {
+		t.Errorf("中译英提示词 = %q", zh)
+	}
+	if !strings.Contains(zh, "大模型 = large language model") || strings.Contains(zh, "vector database") {
+		t.Errorf("只应列出命中的术语: %q", zh)
+	}
+
+	en := tr.systemPrompt("翻译老师", "Translate “Large Language Models need a Vector Database” into Chinese")
+	if !strings.Contains(en, "待翻译内容为英文，请译为中文") || !strings.Contains(en, "大模型 = large language model") || !strings.Contains(en, "向量数据库 = vector database") {
+		t.Errorf("英译中提示词 = %q", en)
+	}
+	if !strings.Contains(en, "译文：") || !strings.Contains(en, "语法要点：") {
+		t.Errorf("提示词缺少输出格式: %q", en)
+	}
+}
+
+func TestTranslationNodeStream(t *testing.T) {
+	ctx := WithSessionID(context.Background(), "u1")
+	answer := "译文：Large language models matter.\n语法要点：\n- matter 在这里是动词，意为“重要”。"
+	cm := fake.NewChatModel(
+		fake.Reply(`{"subject": "english", "confidence": 0.95}`),
+		fake.Reply(answer),
+	)
+	cm.ChunkSize = 8
+	tr, err := NewTranslator([]string{"大模型=large language model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runnable, err := NewGraph(ctx, &GraphConfig{ChatModel: cm, Registry: newTestRegistry(t), Translator: tr, MinConfidence: 0.6})
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	store := newMemorySessionStore(time.Hour)
+	agent := NewSessionGraph(runnable, store)
+
+	question := "把“大模型很重要”翻译成英文"
+	sr, err := agent.Stream(ctx, schema.UserMessage(question))
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var (
+		sb     strings.Builder
+		chunks int
+	)
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		chunks++
+		sb.WriteString(chunk.Content)
+	}
+	sr.Close()
+
+	if sb.String() != answer {
+		t.Errorf("stream output = %q", sb.String())
+	}
+	// 学科节点的回答逐段输出，而不是拼成一条后再输出
+	if chunks < 2 {
+		t.Errorf("stream chunks = %d, want 多段", chunks)
+	}
+	calls := cm.Calls()
+	if !calls[len(calls)-1].Stream {
+		t.Error("翻译节点应以流式调用模型")
+	}
+	system := cm.LastInput()[0].Content
+	if !strings.Contains(system, "请译为英文") || !strings.Contains(system, "大模型 = large language model") {
+		t.Errorf("翻译提示词 = %q", system)
+	}
+
+	saved, _ := store.Load(ctx, "u1")
+	if saved == nil || len(saved.Messages) != 2 || saved.Messages[0].Content != question || saved.Messages[1].Content != answer {
+		t.Errorf("保存的会话 = %+v", saved)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -9,11 +10,15 @@ import (
 	ccb "github.com/cloudwego/eino-ext/callbacks/cozeloop"
 	"github.com/cloudwego/eino/callbacks"
 
-	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/coze-dev/cozeloop-go"
+
+	"common/config"
+	"common/errs"
+	"common/provider"
 )
 
 // PhraseLibrary 短语库结构
@@ -27,13 +32,21 @@ type TagResult struct {
 	Tags []string // 匹配到的标签
 }
 
+// cfg 当前生效的配置，main 启动时从配置文件、环境变量与命令行参数加载
+var cfg = config.NewBase()
+
 func main() {
 	ctx := context.Background()
+	config.MustLoad(flag.NewFlagSet("tag", flag.ExitOnError), os.Args[1:], cfg)
+	errs.Exit(run(ctx))
+}
 
+// run 为示例习题打标签
+func run(ctx context.Context) error {
 	// 增加扣子罗盘trace上报
 	client, err := cozeloop.NewClient()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("创建扣子罗盘客户端失败: %w", err)
 	}
 	defer client.Close(ctx)
 	handler := ccb.NewLoopHandler(client)
@@ -48,31 +61,24 @@ func main() {
 		},
 	}
 
+	chatModel, err := provider.NewChatModel(ctx, &cfg.LLM)
+	if err != nil {
+		return errs.Wrap(errs.ErrModelUnavailable, "创建模型", err)
+	}
+
 	// 测试多个用户习题文本
 	question := "求解一个矩形的长是宽的2倍，周长是30厘米，求长和宽分别是多少？这是一个关于数学几何的问题。"
 	// 基于短语库打标签
-	tags, err := tagWithGraph(ctx, question, phraseLib)
+	tags, err := tagWithGraph(ctx, chatModel, question, phraseLib)
 	if err != nil {
-		fmt.Printf("打标签失败: %v\n", err)
-		return
+		return fmt.Errorf("打标签失败: %w", err)
 	}
 	fmt.Printf("标签: %v\n", tags.Tags)
-
+	return nil
 }
 
 // 基于eino graph的标签功能
-func tagWithGraph(ctx context.Context, text string, lib *PhraseLibrary) (*TagResult, error) {
-	llmKey := os.Getenv("DASHSCOPE_API_KEY")
-
-	chatModel, err := chatOpenAi.NewChatModel(ctx, &chatOpenAi.ChatModelConfig{
-		APIKey:  llmKey,
-		Model:   "qwen-plus",
-		BaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1",
-	})
-	if err != nil {
-		return nil, fmt.Errorf("create chat model failed: %v", err)
-	}
-
+func tagWithGraph(ctx context.Context, chatModel model.BaseChatModel, text string, lib *PhraseLibrary) (*TagResult, error) {
 	g := compose.NewGraph[string, *TagResult]()
 
 	build := compose.InvokableLambda(func(ctx context.Context, input string) ([]*schema.Message, error) {
@@ -85,11 +91,7 @@ func tagWithGraph(ctx context.Context, text string, lib *PhraseLibrary) (*TagRes
 	})
 
 	parse := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (*TagResult, error) {
-		r := &TagResult{Text: text, Tags: make([]string, 0)}
-		out := strings.TrimSpace(input.Content)
-		out = strings.ReplaceAll(out, "，", ",")
-		r.Tags = strings.Split(out, ",")
-		return r, nil
+		return &TagResult{Text: text, Tags: parseTags(input.Content)}, nil
 	})
 
 	_ = g.AddLambdaNode("build", build)
@@ -107,3 +109,14 @@ func tagWithGraph(ctx context.Context, text string, lib *PhraseLibrary) (*TagRes
 	}
 	return runnable.Invoke(ctx, text)
 }
+
+// parseTags 解析模型输出的逗号分隔标签，忽略空白项
+func parseTags(content string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(strings.ReplaceAll(content, "，", ","), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"common/fake"
+)
+
+func TestTagWithGraph(t *testing.T) {
+	lib := &PhraseLibrary{Phrases: []string{"弧长及计算公式", "旋转及旋转的三要素"}}
+
+	tests := []struct {
+		name  string
+		reply string
+		want  []string
+	}{
+		{name: "中文逗号", reply: "弧长及计算公式，旋转及旋转的三要素", want: []string{"弧长及计算公式", "旋转及旋转的三要素"}},
+		{name: "英文逗号与空白", reply: " 弧长及计算公式 , 旋转及旋转的三要素\n", want: []string{"弧长及计算公式", "旋转及旋转的三要素"}},
+		{name: "单个标签", reply: "弧长及计算公式", want: []string{"弧长及计算公式"}},
+		{name: "没有标签", reply: "", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := fake.NewChatModel(fake.Reply(tt.reply))
+			text := "求半径为 2 的圆上 60 度圆心角所对的弧长"
+			result, err := tagWithGraph(context.Background(), cm, text, lib)
+			if err != nil {
+				t.Fatalf("tagWithGraph() error = %v", err)
+			}
+			if result.Text != text || !reflect.DeepEqual(result.Tags, tt.want) {
+				t.Errorf("result = %+v, want tags %q", result, tt.want)
+			}
+
+			// 提示词应包含短语库与原文
+			input := cm.LastInput()
+			if len(input) != 2 || !strings.Contains(input[0].Content, "弧长及计算公式，旋转及旋转的三要素") || !strings.Contains(input[1].Content, text) {
+				t.Errorf("model input = %v", input)
+			}
+		})
+	}
+}
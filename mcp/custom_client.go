@@ -3,22 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"common/errs"
 )
 
-func customClient() {
+// customClient 连接自定义 MCP Server，依次列出并调用 Tools、Resources、Prompts
+func customClient(ctx context.Context) error {
 	// 创建 StreamableHTTP 客户端
-	c, err := client.NewStreamableHttpClient("http://localhost:8080/mcp/")
+	c, err := client.NewStreamableHttpClient(cfg.MCP.URL)
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "创建 MCP 客户端", err)
 	}
 	defer c.Close()
 
-	ctx := context.Background()
-
 	// 初始化
 	initReq := mcp.InitializeRequest{}
 	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
@@ -29,7 +29,7 @@ func customClient() {
 
 	initResult, err := c.Initialize(ctx, initReq)
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "初始化 MCP 客户端", err)
 	}
 
 	fmt.Printf("Connected to server: %s %s\n", initResult.ServerInfo.Name, initResult.ServerInfo.Version)
@@ -37,7 +37,7 @@ func customClient() {
 	// 列出可用的 Tools
 	toolsResult, err := c.ListTools(ctx, mcp.ListToolsRequest{})
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "列出工具", err)
 	}
 
 	fmt.Printf("\nAvailable Tools (%d):\n", len(toolsResult.Tools))
@@ -48,7 +48,7 @@ func customClient() {
 	// 列出可用的 Resources
 	resourcesResult, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "列出资源", err)
 	}
 
 	fmt.Printf("\nAvailable Resources (%d):\n", len(resourcesResult.Resources))
@@ -59,7 +59,7 @@ func customClient() {
 	// 列出可用的 Prompts
 	promptsResult, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "列出 Prompt", err)
 	}
 
 	fmt.Printf("\nAvailable Prompts (%d):\n", len(promptsResult.Prompts))
@@ -79,7 +79,7 @@ func customClient() {
 
 	toolResult, err := c.CallTool(ctx, callReq)
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "调用 calculate 工具", err)
 	}
 
 	// 访问 TextContent 的正确方式
@@ -98,7 +98,7 @@ func customClient() {
 
 	resourceContents, err := c.ReadResource(ctx, readReq)
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "读取服务器配置资源", err)
 	}
 
 	// 访问 TextResourceContents 的正确方式
@@ -120,7 +120,7 @@ func customClient() {
 
 	promptResult, err := c.GetPrompt(ctx, promptReq)
 	if err != nil {
-		log.Fatal(err)
+		return errs.Wrap(errs.ErrToolCall, "获取代码审查 Prompt", err)
 	}
 
 	// 访问 PromptMessage Content 的正确方式
@@ -131,4 +131,5 @@ func customClient() {
 			fmt.Printf("Code review prompt result: %s\n", promptResult.Messages[0].Content)
 		}
 	}
+	return nil
 }
@@ -4,43 +4,50 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"time"
 
-	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
-	mcpp "github.com/cloudwego/eino-ext/components/tool/mcp"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-	"github.com/mark3labs/mcp-go/client"
-	"github.com/mark3labs/mcp-go/mcp"
+
+	"common/errs"
+	"common/provider"
+
+	"mcp/mcpclient"
 )
 
-func einoMcpClient() {
-	ctx := context.Background()
-	mcpTools := getMCPTool(ctx)
+// einoMcpClient 把高德 MCP 工具转为 eino 工具，由模型发起调用
+func einoMcpClient(ctx context.Context) error {
+	mcpClient, err := mcpclient.ConnectSSE(ctx, fmt.Sprintf(cfg.AMap.URL, cfg.AMap.APIKey), "example-client")
+	if err != nil {
+		return err
+	}
+	defer mcpClient.Close()
+	mcpTools, err := mcpclient.EinoTools(ctx, mcpClient)
+	if err != nil {
+		return err
+	}
 
 	// 创建工具节点，用于执行工具调用
 	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: mcpTools})
 	if err != nil {
-		log.Fatalf("创建工具节点失败: %v", err)
+		return errs.Wrap(errs.ErrToolCall, "创建工具节点", err)
 	}
 
 	// 创建模型并绑定工具信息（模型需要 ToolInfo 进行参数对齐）
-	cm, err := createChatModel(ctx)
+	cm, err := provider.NewChatModel(ctx, &cfg.LLM)
 	if err != nil {
-		log.Fatalf("创建聊天模型失败: %v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "创建聊天模型", err)
 	}
 	var toolInfos []*schema.ToolInfo
 	for _, t := range mcpTools {
 		info, err := t.Info(ctx)
 		if err != nil {
-			log.Fatalf("获取工具信息失败: %v", err)
+			return errs.Wrap(errs.ErrToolCall, "获取工具信息", err)
 		}
 		toolInfos = append(toolInfos, info)
 	}
-	if err := cm.BindTools(toolInfos); err != nil {
-		log.Fatalf("绑定工具到模型失败: %v", err)
+	if cm, err = cm.WithTools(toolInfos); err != nil {
+		return fmt.Errorf("绑定工具到模型失败: %w", err)
 	}
 
 	messages := []*schema.Message{
@@ -50,7 +57,7 @@ func einoMcpClient() {
 	//  模型生成，若包含 tool_calls 则执行工具
 	firstResp, err := cm.Generate(ctx, messages)
 	if err != nil {
-		log.Fatalf("模型生成失败: %v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "模型生成", err)
 	}
 
 	fmt.Println("First ToolCalls:", firstResp.ToolCalls)
@@ -60,7 +67,7 @@ func einoMcpClient() {
 		toolOutMsgs, err = toolsNode.Invoke(ctx, &schema.Message{Role: schema.Assistant, ToolCalls: firstResp.ToolCalls})
 	}
 	if err != nil {
-		log.Fatalf("工具执行失败: %v", err)
+		return errs.Wrap(errs.ErrToolCall, "工具执行", err)
 	}
 
 	fmt.Println("工具返回结果：")
@@ -72,15 +79,16 @@ func einoMcpClient() {
 	}
 	fmt.Println("")
 
-	printToolDesc(ctx, mcpTools)
+	return printToolDesc(ctx, mcpTools)
 }
 
-func printToolDesc(ctx context.Context, mcpTools []tool.BaseTool) {
+// printToolDesc 输出工具名称、描述与参数约束
+func printToolDesc(ctx context.Context, mcpTools []tool.BaseTool) error {
 	// 显示tool列表
 	for _, mcpTool := range mcpTools {
 		info, err := mcpTool.Info(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return errs.Wrap(errs.ErrToolCall, "获取工具信息", err)
 		}
 		fmt.Println("Name:", info.Name)
 		fmt.Println("Desc:", info.Desc)
@@ -114,43 +122,5 @@ func printToolDesc(ctx context.Context, mcpTools []tool.BaseTool) {
 		//fmt.Println("Result:", result)
 		fmt.Println()
 	}
-}
-func getMCPTool(ctx context.Context) []tool.BaseTool {
-	client, err := client.NewSSEMCPClient(fmt.Sprintf(amapUrl, amapApiKey))
-	if err != nil {
-		log.Fatal(err)
-	}
-	err = client.Start(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = mcp.Implementation{
-		Name:    "example-client",
-		Version: "1.0.0",
-	}
-
-	_, err = client.Initialize(ctx, initRequest)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	tools, err := mcpp.GetTools(ctx, &mcpp.Config{Cli: client})
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return tools
-}
-
-func createChatModel(ctx context.Context) (*chatOpenAi.ChatModel, error) {
-	llm, err := chatOpenAi.NewChatModel(ctx, &chatOpenAi.ChatModelConfig{
-		APIKey:  llmKey,
-		Model:   chatModel,
-		Timeout: 60 * time.Second,
-		BaseURL: llmApi,
-	})
-	return llm, err
+	return nil
 }
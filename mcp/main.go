@@ -1,30 +1,91 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-)
 
-var (
-	llmKey    = os.Getenv("DASHSCOPE_API_KEY")
-	llmApi    = "https://dashscope.aliyuncs.com/compatible-mode/v1"
-	chatModel = "qwen-plus"
+	"common/config"
+	"common/errs"
 )
 
+// cfg 当前生效的配置，main 启动时从配置文件、环境变量与命令行参数加载
+var cfg = &appConfig{
+	LLM: config.DefaultLLM(),
+	AMap: amapConfig{
+		URL: "https://mcp.amap.com/sse?key=%s",
+	},
+	MCP: customMCPConfig{
+		Addr: ":8080",
+		URL:  "http://localhost:8080/mcp/",
+	},
+}
+
+// appConfig mcp 示例的全部配置
+type appConfig struct {
+	LLM  config.LLM      `yaml:"llm"`
+	AMap amapConfig      `yaml:"amap"`
+	MCP  customMCPConfig `yaml:"mcp"`
+}
+
+// amapConfig 高德 MCP Server
+type amapConfig struct {
+	APIKey string `yaml:"api_key" env:"AMAP_API_KEY" usage:"高德开放平台密钥"`
+	URL    string `yaml:"url" usage:"高德 MCP SSE 地址，%s 处填入密钥"`
+}
+
+// customMCPConfig 自定义 MCP Server
+type customMCPConfig struct {
+	Addr string `yaml:"addr" usage:"自定义 MCP Server 监听地址"`
+	URL  string `yaml:"url" usage:"自定义 MCP Server 访问地址"`
+}
+
+// Validate 校验调用高德 MCP 与大模型所需的配置，自定义 Server/Client 模式无需校验
+func (c *appConfig) Validate() error {
+	var errs []error
+	if c.AMap.APIKey == "" {
+		errs = append(errs, errors.New("未配置 amap.api_key：请设置环境变量 AMAP_API_KEY，或在配置文件中填写"))
+	}
+	return errors.Join(c.LLM.Validate(), errors.Join(errs...))
+}
+
 func main() {
 	// 检查命令行参数来决定运行哪个功能
-	if len(os.Args) == 0 {
-		panic("请指定运行模式: custom-server, custom-client, eino-client")
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "请指定运行模式: custom-server, custom-client, eino-client, amap-client")
+		os.Exit(errs.ExitConfig)
+	}
+	mode := os.Args[1]
+
+	fs := flag.NewFlagSet(mode, flag.ExitOnError)
+	if err := config.Load(fs, os.Args[2:], cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(errs.ExitConfig)
 	}
-	switch os.Args[1] {
+	if mode != "custom-server" && mode != "custom-client" {
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "配置无效: %v\n", err)
+			os.Exit(errs.ExitConfig)
+		}
+	}
+
+	errs.Exit(run(context.Background(), mode))
+}
+
+// run 按模式运行对应示例
+func run(ctx context.Context, mode string) error {
+	switch mode {
 	case "custom-server":
 		// 启动自定义 MCP Server
-		customServer()
+		return customServer()
 	case "custom-client":
 		// 运行HTTP测试客户端
-		customClient()
+		return customClient(ctx)
 	case "eino-client":
-		einoMcpClient()
+		return einoMcpClient(ctx)
 	default:
-		amapMCPClient()
+		return amapMCPClient(ctx)
 	}
 }
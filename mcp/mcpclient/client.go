@@ -0,0 +1,118 @@
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	mcpp "github.com/cloudwego/eino-ext/components/tool/mcp"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"common/errs"
+)
+
+// ConnectSSE 连接 SSE 方式的 MCP Server 并完成初始化，name 为上报给服务端的客户端名称
+func ConnectSSE(ctx context.Context, url, name string) (*client.Client, error) {
+	c, err := client.NewSSEMCPClient(url)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "创建 MCP 客户端", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		c.Close()
+		return nil, errs.Wrap(errs.ErrToolCall, "启动 MCP 客户端", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    name,
+		Version: "1.0.0",
+	}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		c.Close()
+		return nil, errs.Wrap(errs.ErrToolCall, "初始化 MCP 客户端", err)
+	}
+	return c, nil
+}
+
+// ListToolDescriptions 列出服务端的工具，每个工具整理为包含名称、描述与输入 Schema 的一行 JSON
+func ListToolDescriptions(ctx context.Context, c *client.Client) ([]string, error) {
+	listToolsResult, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "列出工具", err)
+	}
+
+	var toolsDesc []string
+	for _, t := range listToolsResult.Tools {
+		b, _ := json.Marshal(map[string]any{
+			"name":        t.Name,
+			"desc":        t.Description,
+			"inputSchema": t.InputSchema,
+		})
+		toolsDesc = append(toolsDesc, string(b))
+	}
+	return toolsDesc, nil
+}
+
+// EinoTools 把服务端的全部工具封装为 eino 工具
+func EinoTools(ctx context.Context, c *client.Client) ([]tool.BaseTool, error) {
+	tools, err := mcpp.GetTools(ctx, &mcpp.Config{Cli: c})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "获取 MCP 工具", err)
+	}
+	return tools, nil
+}
+
+// ToolChoice 由模型决定使用的工具及参数
+type ToolChoice struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// ChooseTool 由模型从工具列表中选择一个工具，toolsDesc 为 ListToolDescriptions 的结果
+func ChooseTool(ctx context.Context, cm model.BaseChatModel, toolsDesc []string, userInput string) (*ToolChoice, error) {
+	sys := "你是一位工具选择助手。根据用户问题和提供的工具列表，选择最合适的一个工具，并以严格的JSON输出：{\"tool\": <工具名>, \"arguments\": <参数对象>}。不要输出除JSON以外的内容。"
+	toolList := strings.Join(toolsDesc, "\n")
+	prompt := fmt.Sprintf("可用工具列表(包含名称、描述、输入Schema)：\n%s\n\n用户问题：%s\n\n仅输出JSON。", toolList, userInput)
+
+	resp, err := cm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(sys),
+		schema.UserMessage(prompt),
+	}, model.WithTemperature(0))
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrModelUnavailable, "模型选择工具", err)
+	}
+	var choice ToolChoice
+	if err := json.Unmarshal([]byte(resp.Content), &choice); err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "解析模型选择的工具", err)
+	}
+	if choice.Tool == "" {
+		return nil, errs.New(errs.ErrToolCall, "选择工具")
+	}
+	return &choice, nil
+}
+
+// PrintToolResult 打印工具调用结果
+func PrintToolResult(w io.Writer, result *mcp.CallToolResult) {
+	if result == nil {
+		fmt.Fprintln(w, "No result returned")
+		return
+	}
+
+	fmt.Fprintf(w, "Tool Result:\n")
+	if result.Content != nil {
+		for i, content := range result.Content {
+			fmt.Fprintf(w, "Content[%d]: %+v\n", i, content)
+		}
+	}
+
+	if result.IsError {
+		fmt.Fprintf(w, "Error occurred in tool execution\n")
+	}
+}
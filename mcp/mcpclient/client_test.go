@@ -0,0 +1,97 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+
+	"common/config"
+	"common/errs"
+	"common/provider"
+	"common/resilience"
+)
+
+// toolChoiceCompletion 模型选择 maps_weather 的 chat completion 响应
+const toolChoiceCompletion = `{"id":"1","object":"chat.completion","model":"test","choices":[{"index":0,"finish_reason":"stop",` +
+	`"message":{"role":"assistant","content":"{\"tool\":\"maps_weather\",\"arguments\":{\"city\":\"北京\"}}"}}]}`
+
+// newTestModel 经 provider 创建指向 handler 模拟的 OpenAI 兼容服务的模型
+func newTestModel(t *testing.T, handler http.HandlerFunc, policy config.Resilience) model.BaseChatModel {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	llm := config.LLM{Provider: "openai-compatible", BaseURL: srv.URL, ChatModel: "test", Resilience: policy}
+	if err := llm.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	cm, err := provider.NewChatModel(context.Background(), &llm)
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	return cm
+}
+
+func TestChooseToolRetries(t *testing.T) {
+	var calls atomic.Int32
+	cm := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, `{"error":{"message":"busy"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, toolChoiceCompletion)
+	}, config.Resilience{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	choice, err := ChooseTool(context.Background(), cm, []string{`{"name":"maps_weather"}`}, "北京天气")
+	if err != nil {
+		t.Fatalf("ChooseTool() error = %v", err)
+	}
+	if choice.Tool != "maps_weather" || choice.Arguments["city"] != "北京" {
+		t.Errorf("choice = %+v", choice)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server called %d times, want 2", n)
+	}
+}
+
+func TestChooseToolCircuitOpen(t *testing.T) {
+	var calls atomic.Int32
+	cm := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error":{"message":"down"}}`, http.StatusInternalServerError)
+	}, config.Resilience{FailureThreshold: 1, Cooldown: time.Minute})
+
+	ctx := context.Background()
+	if _, err := ChooseTool(ctx, cm, nil, "北京天气"); !errors.Is(err, errs.ErrModelUnavailable) {
+		t.Fatalf("first call error = %v, want ErrModelUnavailable", err)
+	}
+	// 熔断后不再请求服务
+	_, err := ChooseTool(ctx, cm, nil, "北京天气")
+	if !errors.Is(err, resilience.ErrCircuitOpen) || !errors.Is(err, errs.ErrModelUnavailable) {
+		t.Errorf("second call error = %v, want ErrCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server called %d times, want 1", n)
+	}
+}
+
+func TestChooseToolInvalidJSON(t *testing.T) {
+	cm := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","model":"test","choices":[{"index":0,"finish_reason":"stop",`+
+			`"message":{"role":"assistant","content":"我会使用 maps_weather 工具"}}]}`)
+	}, config.Resilience{})
+
+	_, err := ChooseTool(context.Background(), cm, nil, "北京天气")
+	if !errors.Is(err, errs.ErrToolCall) {
+		t.Errorf("error = %v, want ErrToolCall", err)
+	}
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+)
+
+// ErrMaxSteps 模型在步数上限内仍在请求工具
+var ErrMaxSteps = errors.New("超过最大工具调用轮数")
+
+const (
+	nodeKeyModel = "chat_model"
+	nodeKeyTools = "tools"
+
+	defaultMaxSteps = 5
+)
+
+// Config 工具调用循环的配置
+type Config struct {
+	Model model.ToolCallingChatModel
+	Tools []tool.BaseTool
+	// MaxSteps 最多执行的工具调用轮数，<=0 时为 5
+	MaxSteps int
+	// StreamToolCallChecker 流式输出时判断模型是否请求了工具，为空时按第一个非空分片判断
+	// 先输出文本再输出 tool_calls 的模型需要自定义为读完整个流
+	StreamToolCallChecker func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error)
+}
+
+// Agent ReAct 式工具调用循环：模型生成，若包含 tool_calls 则执行工具并把结果交回模型，
+// 直到模型不再请求工具或达到步数上限
+type Agent struct {
+	runnable compose.Runnable[[]*schema.Message, *schema.Message]
+}
+
+// Step 一轮工具调用
+type Step struct {
+	Request *schema.Message   // 模型发起的 tool_calls
+	Results []*schema.Message // 工具返回，与 Request.ToolCalls 一一对应
+}
+
+// Transcript 一次运行的完整过程
+type Transcript struct {
+	Steps  []Step
+	Answer *schema.Message // 模型的最终回答
+}
+
+// agentState 单次运行的状态
+type agentState struct {
+	Messages   []*schema.Message
+	transcript *Transcript
+	pending    *schema.Message // 已发起、尚未拿到结果的 tool_calls
+}
+
+// transcriptKey 在 context 中传递本次运行的 Transcript
+type transcriptKey struct{}
+
+// New 绑定工具并编译 模型 → 分支 → 工具 → 模型 的 Graph
+func New(ctx context.Context, conf *Config) (*Agent, error) {
+	maxSteps := conf.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+	checker := conf.StreamToolCallChecker
+	if checker == nil {
+		checker = firstChunkToolCallChecker
+	}
+
+	toolInfos := make([]*schema.ToolInfo, 0, len(conf.Tools))
+	for _, t := range conf.Tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, errs.Wrap(errs.ErrToolCall, "获取工具信息", err)
+		}
+		toolInfos = append(toolInfos, info)
+	}
+	chatModel, err := conf.Model.WithTools(toolInfos)
+	if err != nil {
+		return nil, fmt.Errorf("绑定工具到模型失败: %w", err)
+	}
+	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: conf.Tools})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "创建工具节点", err)
+	}
+
+	graph := compose.NewGraph[[]*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *agentState {
+		t, _ := ctx.Value(transcriptKey{}).(*Transcript)
+		if t == nil {
+			t = &Transcript{}
+		}
+		return &agentState{transcript: t}
+	}))
+
+	// 模型的输入为首轮消息或上一轮的工具结果，追加到历史后整体交给模型
+	modelPreHandler := func(ctx context.Context, in []*schema.Message, st *agentState) ([]*schema.Message, error) {
+		st.Messages = append(st.Messages, in...)
+		if st.pending != nil {
+			st.transcript.Steps = append(st.transcript.Steps, Step{Request: st.pending, Results: in})
+			st.pending = nil
+		}
+		return st.Messages, nil
+	}
+	toolsPreHandler := func(ctx context.Context, in *schema.Message, st *agentState) (*schema.Message, error) {
+		if len(st.transcript.Steps) >= maxSteps {
+			return nil, fmt.Errorf("%w: %d", ErrMaxSteps, maxSteps)
+		}
+		st.Messages = append(st.Messages, in)
+		st.pending = in
+		return in, nil
+	}
+
+	_ = graph.AddChatModelNode(nodeKeyModel, chatModel, compose.WithStatePreHandler(modelPreHandler), compose.WithNodeName(nodeKeyModel))
+	_ = graph.AddToolsNode(nodeKeyTools, toolsNode, compose.WithStatePreHandler(toolsPreHandler), compose.WithNodeName(nodeKeyTools))
+	_ = graph.AddEdge(compose.START, nodeKeyModel)
+	_ = graph.AddBranch(nodeKeyModel, compose.NewStreamGraphBranch(func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (string, error) {
+		isToolCall, err := checker(ctx, sr)
+		if err != nil {
+			return "", err
+		}
+		if isToolCall {
+			return nodeKeyTools, nil
+		}
+		return compose.END, nil
+	}, map[string]bool{nodeKeyTools: true, compose.END: true}))
+	_ = graph.AddEdge(nodeKeyTools, nodeKeyModel)
+
+	// 每轮包含模型与工具两个节点，再加上最终回答的一次模型调用
+	runnable, err := graph.Compile(ctx,
+		compose.WithMaxRunSteps(2*maxSteps+2),
+		compose.WithNodeTriggerMode(compose.AnyPredecessor),
+		compose.WithGraphName("ToolAgent"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("编译 Graph 失败: %w", err)
+	}
+	return &Agent{runnable: runnable}, nil
+}
+
+// Invoke 运行到模型给出最终回答，返回回答与每一轮的记录
+// 出错时 Transcript 中保留已完成的轮次
+func (a *Agent) Invoke(ctx context.Context, messages []*schema.Message) (*schema.Message, *Transcript, error) {
+	t := &Transcript{}
+	out, err := a.runnable.Invoke(context.WithValue(ctx, transcriptKey{}, t), messages)
+	if err != nil {
+		return nil, t, wrapRunError(err)
+	}
+	t.Answer = out
+	return out, t, nil
+}
+
+// Stream 流式输出最终回答，工具调用轮次在内部完成
+// Transcript 在读到流结束后才完整
+func (a *Agent) Stream(ctx context.Context, messages []*schema.Message) (*schema.StreamReader[*schema.Message], *Transcript, error) {
+	t := &Transcript{}
+	sr, err := a.runnable.Stream(context.WithValue(ctx, transcriptKey{}, t), messages)
+	if err != nil {
+		return nil, t, wrapRunError(err)
+	}
+
+	out, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer sw.Close()
+		defer sr.Close()
+		var chunks []*schema.Message
+		for {
+			chunk, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				sw.Send(nil, wrapRunError(err))
+				return
+			}
+			chunks = append(chunks, chunk)
+			if sw.Send(chunk, nil) {
+				return
+			}
+		}
+		if answer, err := schema.ConcatMessages(chunks); err == nil {
+			t.Answer = answer
+		}
+	}()
+	return out, t, nil
+}
+
+// wrapRunError 工具执行失败归为 ErrToolCall，模型失败已由模型包装器归类
+func wrapRunError(err error) error {
+	if errors.Is(err, ErrMaxSteps) || errors.Is(err, errs.ErrModelUnavailable) || errors.Is(err, errs.ErrConfig) {
+		return err
+	}
+	return errs.Wrap(errs.ErrToolCall, "运行工具调用循环", err)
+}
+
+// firstChunkToolCallChecker 跳过开头的空分片，按第一个非空分片是否包含 tool_calls 判断
+func firstChunkToolCallChecker(_ context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	defer sr.Close()
+	for {
+		msg, err := sr.Recv()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(msg.ToolCalls) > 0 {
+			return true, nil
+		}
+		if msg.Content != "" {
+			return false, nil
+		}
+	}
+}
+
+// Print 输出每一轮的工具调用与结果
+func (t *Transcript) Print(w io.Writer) {
+	for i, step := range t.Steps {
+		fmt.Fprintf(w, "第 %d 轮工具调用：\n", i+1)
+		for j, call := range step.Request.ToolCalls {
+			fmt.Fprintf(w, "  -> %s(%s)\n", call.Function.Name, call.Function.Arguments)
+			if j < len(step.Results) {
+				fmt.Fprintf(w, "  <- %s\n", step.Results[j].Content)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"common/errs"
+	"common/fake"
+
+	"tools/search"
+)
+
+// newTestAgent 用用户查询工具与脚本模型创建 Agent
+func newTestAgent(t *testing.T, cm *fake.ChatModel, maxSteps int) *Agent {
+	t.Helper()
+	repo, err := search.OpenUserRepository(context.Background(), filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("OpenUserRepository() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	userTool, err := search.NewUserInfoTool(repo)
+	if err != nil {
+		t.Fatalf("NewUserInfoTool() error = %v", err)
+	}
+	a, err := New(context.Background(), &Config{Model: cm, Tools: []tool.BaseTool{userTool}, MaxSteps: maxSteps})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+func TestAgentInvoke(t *testing.T) {
+	messages := []*schema.Message{
+		schema.SystemMessage("你可使用提供的工具回答问题。"),
+		schema.UserMessage("张三和李四是同一家公司的吗？"),
+	}
+
+	tests := []struct {
+		name       string
+		replies    []*schema.Message
+		wantAnswer string
+		wantSteps  []string // 每轮工具返回中应包含的内容
+	}{
+		{
+			name: "多轮工具调用",
+			replies: []*schema.Message{
+				fake.ToolCallReply(fake.ToolCall("search_user_info", `{"name":"张三"}`)),
+				fake.ToolCallReply(fake.ToolCall("search_user_info", `{"name":"李四"}`)),
+				fake.Reply("不是，张三在阿里巴巴，李四在字节跳动。"),
+			},
+			wantAnswer: "不是，张三在阿里巴巴，李四在字节跳动。",
+			wantSteps:  []string{"zhangsan@example.com", "lisi@example.com"},
+		},
+		{
+			name: "一轮并行调用",
+			replies: []*schema.Message{
+				fake.ToolCallReply(
+					fake.ToolCall("search_user_info", `{"name":"张三"}`),
+					fake.ToolCall("search_user_info", `{"name":"李四"}`),
+				),
+				fake.Reply("不是同一家公司。"),
+			},
+			wantAnswer: "不是同一家公司。",
+			wantSteps:  []string{"lisi@example.com"},
+		},
+		{
+			name:       "不调用工具",
+			replies:    []*schema.Message{fake.Reply("请提供用户名。")},
+			wantAnswer: "请提供用户名。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := fake.NewChatModel(tt.replies...)
+			answer, transcript, err := newTestAgent(t, cm, 0).Invoke(context.Background(), messages)
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if answer.Content != tt.wantAnswer || transcript.Answer != answer {
+				t.Errorf("answer = %q, transcript answer = %v", answer.Content, transcript.Answer)
+			}
+			if len(transcript.Steps) != len(tt.wantSteps) {
+				t.Fatalf("steps = %d, want %d", len(transcript.Steps), len(tt.wantSteps))
+			}
+			for i, step := range transcript.Steps {
+				if len(step.Results) != len(step.Request.ToolCalls) {
+					t.Errorf("step %d: %d results for %d calls", i, len(step.Results), len(step.Request.ToolCalls))
+				}
+				last := step.Results[len(step.Results)-1]
+				if !strings.Contains(last.Content, tt.wantSteps[i]) {
+					t.Errorf("step %d result = %q, want %q", i, last.Content, tt.wantSteps[i])
+				}
+			}
+
+			// 每次调用模型都带上此前的全部消息
+			calls := cm.Calls()
+			if len(calls) != len(tt.wantSteps)+1 {
+				t.Fatalf("model called %d times", len(calls))
+			}
+			final := calls[len(calls)-1].Input
+			if want := len(messages) + 2*len(tt.wantSteps); len(transcript.Steps) > 0 && len(final) < want {
+				t.Errorf("final input has %d messages, want at least %d", len(final), want)
+			}
+			if len(calls[0].Tools) != 1 || calls[0].Tools[0].Name != "search_user_info" {
+				t.Errorf("tools bound = %v", calls[0].Tools)
+			}
+		})
+	}
+	if len(messages) != 2 {
+		t.Errorf("Invoke 修改了调用方的消息切片")
+	}
+}
+
+func TestAgentInvokeErrors(t *testing.T) {
+	lookup := fake.ToolCallReply(fake.ToolCall("search_user_info", `{"name":"张三"}`))
+
+	t.Run("超过步数上限", func(t *testing.T) {
+		cm := &fake.ChatModel{Respond: func(context.Context, []*schema.Message) (*schema.Message, error) {
+			return lookup, nil
+		}}
+		_, transcript, err := newTestAgent(t, cm, 2).Invoke(context.Background(), []*schema.Message{schema.UserMessage("查询张三")})
+		if !errors.Is(err, ErrMaxSteps) {
+			t.Fatalf("Invoke() error = %v, want ErrMaxSteps", err)
+		}
+		if len(transcript.Steps) != 2 {
+			t.Errorf("completed steps = %d, want 2", len(transcript.Steps))
+		}
+	})
+
+	t.Run("调用不存在的工具", func(t *testing.T) {
+		cm := fake.NewChatModel(fake.ToolCallReply(fake.ToolCall("search_order", `{}`)))
+		_, _, err := newTestAgent(t, cm, 0).Invoke(context.Background(), []*schema.Message{schema.UserMessage("查询订单")})
+		if !errors.Is(err, errs.ErrToolCall) {
+			t.Errorf("Invoke() error = %v, want ErrToolCall", err)
+		}
+	})
+}
+
+func TestAgentStream(t *testing.T) {
+	cm := fake.NewChatModel(
+		fake.ToolCallReply(fake.ToolCall("search_user_info", `{"name":"王五"}`)),
+		fake.Reply("王五是华为的产品经理。"),
+	)
+	cm.ChunkSize = 2
+
+	sr, transcript, err := newTestAgent(t, cm, 0).Stream(context.Background(), []*schema.Message{schema.UserMessage("王五是做什么的？")})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var chunks []string
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		chunks = append(chunks, chunk.Content)
+	}
+
+	if got := strings.Join(chunks, ""); got != "王五是华为的产品经理。" || len(chunks) < 2 {
+		t.Errorf("chunks = %q", chunks)
+	}
+	if len(transcript.Steps) != 1 || !strings.Contains(transcript.Steps[0].Results[0].Content, "wangwu@example.com") {
+		t.Errorf("transcript steps = %+v", transcript.Steps)
+	}
+	if transcript.Answer == nil || transcript.Answer.Content != "王五是华为的产品经理。" {
+		t.Errorf("transcript answer = %v", transcript.Answer)
+	}
+	for _, call := range cm.Calls() {
+		if !call.Stream {
+			t.Errorf("model called without streaming")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"common/config"
+)
+
+// cfg 当前生效的配置，main 启动时从配置文件、环境变量与命令行参数加载
+var cfg = defaultConfig()
+
+// appConfig tools 的全部配置
+type appConfig struct {
+	LLM    config.LLM   `yaml:"llm"`
+	UserDB userDBConfig `yaml:"user_db"`
+}
+
+// userDBConfig 用户信息数据库
+type userDBConfig struct {
+	Path string `yaml:"path" env:"USER_DB_PATH" usage:"SQLite 数据库文件，不存在时创建并写入示例数据"`
+}
+
+// defaultConfig 默认配置
+func defaultConfig() *appConfig {
+	return &appConfig{
+		LLM:    config.DefaultLLM(),
+		UserDB: userDBConfig{Path: "./users.db"},
+	}
+}
+
+// Validate 校验全部配置
+func (c *appConfig) Validate() error {
+	var err error
+	if c.UserDB.Path == "" {
+		err = errors.New("未配置 user_db.path")
+	}
+	return errors.Join(c.LLM.Validate(), err)
+}
@@ -2,72 +2,55 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"time"
 
-	chatOpenAi "github.com/cloudwego/eino-ext/components/model/openai"
-	duckduckgo "github.com/cloudwego/eino-ext/components/tool/duckduckgo/v2"
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-)
 
-var (
-	llmKey    = os.Getenv("DASHSCOPE_API_KEY")
-	llmApi    = "https://dashscope.aliyuncs.com/compatible-mode/v1"
-	chatModel = "qwen-plus"
+	"common/config"
+	"common/errs"
+	"common/provider"
+
+	"tools/agent"
+	"tools/search"
 )
 
 func main() {
+	config.MustLoad(flag.NewFlagSet("tools", flag.ExitOnError), os.Args[1:], cfg)
+	ctx := context.Background()
+
 	// 使用网页搜索工具回答用户问题
-	searchWeb()
+	errs.Exit(SearchWeb(ctx))
 	// 使用自定义数据库查询工具回答问题
-	//searchDB()
+	//errs.Exit(SearchDB(ctx))
 }
 
-func searchDB() {
-	ctx := context.Background()
+// SearchDB 使用自定义数据库查询工具回答问题
+func SearchDB(ctx context.Context) error {
 
-	// 创建模型并绑定工具（演示完整链路）
-	cm, err := createChatModel(ctx)
+	// 创建模型（演示完整链路）
+	cm, err := provider.NewChatModel(ctx, &cfg.LLM)
 	if err != nil {
-		log.Fatalf("创建聊天模型失败: %v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "创建聊天模型", err)
 	}
-	// 获取
-	userTool, err := search_user_info()
-
-	toolInfo, _ := userTool.Info(ctx)
-	if err := cm.BindTools([]*schema.ToolInfo{toolInfo}); err != nil {
-		log.Fatalf("绑定工具到模型失败: %v", err)
+	// 打开用户数据库，首次运行时建表并写入示例数据
+	repo, err := search.OpenUserRepository(ctx, cfg.UserDB.Path)
+	if err != nil {
+		return errs.Wrap(errs.ErrToolCall, "打开用户数据库", err)
 	}
-
-	// 创建工具节点，用于执行工具调用
-	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: []tool.BaseTool{userTool}})
+	defer repo.Close()
+	userTool, err := search.NewUserInfoTool(repo)
 	if err != nil {
-		log.Fatalf("创建工具节点失败: %v", err)
+		return err
 	}
 
-	var toolOutMsgs []*schema.Message // 工具输出消息
-	var toolsMessage *schema.Message  // 工具会话消息
-
 	// 3) 构造用户消息，提示模型可以调用工具
 	messages := []*schema.Message{
 		{Role: schema.System, Content: "你可使用提供的工具回答问题。尽量直接查出"},
-		{Role: schema.User, Content: "查询张三信息。"},
-	}
-	//  模型生成，若包含 tool_calls 则执行工具
-	firstResp, err := cm.Generate(ctx, messages)
-	if err != nil {
-		log.Fatalf("模型生成失败: %v", err)
-	}
-	if len(firstResp.ToolCalls) > 0 {
-		toolsMessage = &schema.Message{Role: schema.Assistant, ToolCalls: firstResp.ToolCalls}
-		toolOutMsgs, err = toolsNode.Invoke(ctx, toolsMessage)
-		if err != nil {
-			log.Fatalf("工具执行失败: %v", err)
-		}
+		{Role: schema.User, Content: "姓张的用户分别在哪家公司？"},
 	}
 
 	// 直接构造一次工具调用（可替代模型生成的 tool_calls）
@@ -84,37 +67,34 @@ func searchDB() {
 	// 	},
 	// }
 
-	for _, m := range toolOutMsgs {
-		if m.Content != "" {
-			fmt.Println(m.Content)
-		}
+	a, err := agent.New(ctx, &agent.Config{Model: cm, Tools: []tool.BaseTool{userTool}})
+	if err != nil {
+		return err
 	}
+	finalResp, transcript, err := a.Invoke(ctx, messages)
+	if err != nil {
+		return err
+	}
+	transcript.Print(os.Stdout)
+
+	fmt.Println("最终回答：")
+	fmt.Println(finalResp.Content)
+	return nil
 }
 
-func searchWeb() {
-	ctx := context.Background()
+// SearchWeb 使用网页搜索工具回答问题
+func SearchWeb(ctx context.Context) error {
 
 	// 网页查询工具
-	textSearchTool, err := duckduckgo.NewTextSearchTool(ctx, &duckduckgo.Config{
-		MaxResults: 3,
-		Region:     duckduckgo.RegionUS,
-	})
+	textSearchTool, err := search.NewWebSearchTool(ctx, 3)
 	if err != nil {
-		log.Fatalf("NewTool of duckduckgo failed, err=%v", err)
+		return err
 	}
-	toolInfo, _ := textSearchTool.Info(ctx)
 
 	// 创建
-	cm, err := createChatModel(ctx)
+	cm, err := provider.NewChatModel(ctx, &cfg.LLM)
 	if err != nil {
-		log.Fatalf("Failed to create chat model: %v", err)
-	}
-	cm.BindTools([]*schema.ToolInfo{toolInfo})
-
-	// 创建工具节点，用于执行模型发起的 tool_calls
-	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: []tool.BaseTool{textSearchTool}})
-	if err != nil {
-		log.Fatalf("创建工具节点失败: %v", err)
+		return errs.Wrap(errs.ErrModelUnavailable, "创建聊天模型", err)
 	}
 
 	// 3) 构造用户消息，提示模型可以调用工具
@@ -123,8 +103,6 @@ func searchWeb() {
 		{Role: schema.User, Content: "查询北京天气，并给出出行建议。"},
 	}
 
-	var toolOutMsgs []*schema.Message // 工具输出消息
-	var toolsMessage *schema.Message  // 工具会话消息
 	// 直接拼装function调用
 	// toolsMessage := &schema.Message{
 	// 	Role:    schema.Assistant,
@@ -140,49 +118,28 @@ func searchWeb() {
 	// }
 	// toolOutMsgs, err = toolsNode.Invoke(ctx, toolsMessage)
 
-	//  模型生成，若包含 tool_calls 则执行工具
-	firstResp, err := cm.Generate(ctx, messages)
+	a, err := agent.New(ctx, &agent.Config{Model: cm, Tools: []tool.BaseTool{textSearchTool}})
 	if err != nil {
-		log.Fatalf("模型生成失败: %v", err)
-	}
-
-	if len(firstResp.ToolCalls) > 0 {
-		toolsMessage = &schema.Message{Role: schema.Assistant, ToolCalls: firstResp.ToolCalls}
-		toolOutMsgs, err = toolsNode.Invoke(ctx, toolsMessage)
-		if err != nil {
-			log.Fatalf("工具执行失败: %v", err)
-		}
-	}
-
-	fmt.Println("工具返回结果：")
-	for i, m := range toolOutMsgs {
-		fmt.Printf("  [%d] role=%s\n", i+1, m.Role)
-		if m.Content != "" {
-			fmt.Println(m.Content)
-		}
+		return err
 	}
-	fmt.Println("")
-
-	// 组织工具结果进入上下文，再次让模型生成最终回答
-	finalMessages := messages
-	finalMessages = append(finalMessages, toolsMessage)
-	finalMessages = append(finalMessages, toolOutMsgs...)
-
-	finalResp, err := cm.Generate(ctx, finalMessages)
+	sr, transcript, err := a.Stream(ctx, messages)
 	if err != nil {
-		log.Fatalf("最终生成失败: %v", err)
+		return err
 	}
+	defer sr.Close()
 
 	fmt.Println("最终回答：")
-	fmt.Println(finalResp.Content)
-}
-
-func createChatModel(ctx context.Context) (*chatOpenAi.ChatModel, error) {
-	llm, err := chatOpenAi.NewChatModel(ctx, &chatOpenAi.ChatModelConfig{
-		APIKey:  llmKey,
-		Model:   chatModel,
-		Timeout: 60 * time.Second,
-		BaseURL: llmApi,
-	})
-	return llm, err
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(chunk.Content)
+	}
+	fmt.Println()
+	transcript.Print(os.Stdout)
+	return nil
 }
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+
+	"common/errs"
+)
+
+// UserQueryParams 用户查询工具的参数，由模型按 jsonschema 标签生成
+type UserQueryParams struct {
+	Name     string `json:"name,omitempty" jsonschema:"description=要查询的用户名"`
+	Email    string `json:"email,omitempty" jsonschema:"description=要查询的邮箱，精确匹配"`
+	Company  string `json:"company,omitempty" jsonschema:"description=要查询的公司"`
+	Fuzzy    bool   `json:"fuzzy,omitempty" jsonschema:"description=为 true 时用户名与公司按包含匹配，例如只知道姓氏时"`
+	Page     int    `json:"page,omitempty" jsonschema:"description=页码，从 1 开始，默认 1"`
+	PageSize int    `json:"page_size,omitempty" jsonschema:"description=每页条数，默认 10，最大 50"`
+}
+
+// UserInfo 一条用户信息
+type UserInfo struct {
+	Name    string `json:"name"`
+	Company string `json:"company"`
+	Title   string `json:"title"`
+	Email   string `json:"email"`
+}
+
+// QueryUserInfo 按参数查询用户，返回交给模型的 JSON；参数缺失或未找到时返回说明而不是错误
+func QueryUserInfo(ctx context.Context, repo *UserRepository, p *UserQueryParams) (string, error) {
+	if p == nil || strings.TrimSpace(p.Name+p.Email+p.Company) == "" {
+		b, _ := json.Marshal(map[string]any{"error": "name, email or company is required"})
+		return string(b), nil
+	}
+
+	page, err := repo.Search(ctx, UserQuery{
+		Name:     p.Name,
+		Email:    p.Email,
+		Company:  p.Company,
+		Fuzzy:    p.Fuzzy,
+		Page:     p.Page,
+		PageSize: p.PageSize,
+	})
+	if err != nil {
+		return "", err
+	}
+	if page.Total == 0 {
+		b, _ := json.Marshal(map[string]any{"found": false, "msg": "user not found"})
+		return string(b), nil
+	}
+	b, _ := json.Marshal(map[string]any{
+		"found":     true,
+		"total":     page.Total,
+		"page":      page.Page,
+		"page_size": page.PageSize,
+		"users":     page.Users,
+	})
+	return string(b), nil
+}
+
+// NewUserInfoTool 创建 search_user_info 工具
+func NewUserInfoTool(repo *UserRepository) (tool.InvokableTool, error) {
+	// 使用 InferTool 快速构建可调用工具
+	userTool, err := utils.InferTool(
+		"search_user_info",
+		"查询用户信息（姓名、公司、职位、邮箱）。可按用户名、邮箱或公司查询，条件可组合；只知道部分姓名时设置 fuzzy；结果较多时按 page 翻页",
+		func(ctx context.Context, p *UserQueryParams) (string, error) {
+			return QueryUserInfo(ctx, repo, p)
+		},
+	)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "创建用户查询工具", err)
+	}
+
+	return userTool, nil
+}
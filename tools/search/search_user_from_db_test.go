@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// openTestRepository 在临时目录中创建带示例数据的用户数据库
+func openTestRepository(t *testing.T) *UserRepository {
+	t.Helper()
+	repo, err := OpenUserRepository(context.Background(), filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("OpenUserRepository() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestQueryUserInfo(t *testing.T) {
+	repo := openTestRepository(t)
+	tests := []struct {
+		name      string
+		params    *UserQueryParams
+		wantFound any
+		wantKey   string
+		wantTotal float64
+	}{
+		{name: "存在的用户", params: &UserQueryParams{Name: "张三"}, wantFound: true, wantKey: "users", wantTotal: 1},
+		{name: "模糊查询", params: &UserQueryParams{Name: "张", Fuzzy: true}, wantFound: true, wantKey: "users", wantTotal: 2},
+		{name: "按公司查询", params: &UserQueryParams{Company: "阿里巴巴"}, wantFound: true, wantKey: "users", wantTotal: 3},
+		{name: "不存在的用户", params: &UserQueryParams{Name: "赵六"}, wantFound: false, wantKey: "msg"},
+		{name: "空用户名", params: &UserQueryParams{Name: "  "}, wantKey: "error"},
+		{name: "缺少参数", params: nil, wantKey: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := QueryUserInfo(context.Background(), repo, tt.params)
+			if err != nil {
+				t.Fatalf("QueryUserInfo() error = %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("输出不是 JSON: %s", out)
+			}
+			if got["found"] != tt.wantFound {
+				t.Errorf("found = %v, want %v", got["found"], tt.wantFound)
+			}
+			if _, ok := got[tt.wantKey]; !ok {
+				t.Errorf("输出缺少 %s: %s", tt.wantKey, out)
+			}
+			if tt.wantTotal > 0 && got["total"] != tt.wantTotal {
+				t.Errorf("total = %v, want %v", got["total"], tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestUserInfoToolSchema(t *testing.T) {
+	userTool, err := NewUserInfoTool(openTestRepository(t))
+	if err != nil {
+		t.Fatalf("NewUserInfoTool() error = %v", err)
+	}
+	info, err := userTool.Info(context.Background())
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	js, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+	for _, name := range []string{"name", "email", "company", "fuzzy", "page", "page_size"} {
+		if _, ok := js.Properties.Get(name); !ok {
+			t.Errorf("参数缺少 %s", name)
+		}
+	}
+	if len(js.Required) != 0 {
+		t.Errorf("required = %v，各条件均应可选", js.Required)
+	}
+}
@@ -0,0 +1,224 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite" // 纯 Go 实现，CGO_ENABLED=0 时也能编译
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// userMigrations 按顺序执行的表结构变更，已执行的版本记录在 schema_migrations 中
+// 只能在末尾追加，不能修改已发布的语句
+var userMigrations = []string{
+	`CREATE TABLE users (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		name       TEXT NOT NULL,
+		company    TEXT NOT NULL DEFAULT '',
+		title      TEXT NOT NULL DEFAULT '',
+		email      TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX idx_users_name ON users(name);
+	 CREATE INDEX idx_users_company ON users(company)`,
+}
+
+// seedUsers 空库时写入的示例数据
+var seedUsers = []UserInfo{
+	{Name: "张三", Company: "阿里巴巴", Title: "后端工程师", Email: "zhangsan@example.com"},
+	{Name: "李四", Company: "字节跳动", Title: "数据分析师", Email: "lisi@example.com"},
+	{Name: "王五", Company: "华为", Title: "产品经理", Email: "wangwu@example.com"},
+	{Name: "张伟", Company: "阿里巴巴", Title: "前端工程师", Email: "zhangwei@example.com"},
+	{Name: "赵敏", Company: "腾讯", Title: "算法工程师", Email: "zhaomin@example.com"},
+	{Name: "钱多多", Company: "字节跳动", Title: "后端工程师", Email: "qianduoduo@example.com"},
+	{Name: "孙丽", Company: "华为", Title: "测试工程师", Email: "sunli@example.com"},
+	{Name: "周杰", Company: "腾讯", Title: "产品经理", Email: "zhoujie@example.com"},
+	{Name: "吴磊", Company: "阿里巴巴", Title: "运维工程师", Email: "wulei@example.com"},
+	{Name: "郑爽", Company: "美团", Title: "设计师", Email: "zhengshuang@example.com"},
+	{Name: "王小明", Company: "美团", Title: "后端工程师", Email: "wangxiaoming@example.com"},
+	{Name: "李娜", Company: "百度", Title: "数据分析师", Email: "lina@example.com"},
+}
+
+// UserQuery 查询条件，各条件之间为且的关系，至少需要一个条件
+type UserQuery struct {
+	Name    string
+	Email   string
+	Company string
+	// Fuzzy 为 true 时姓名与公司按包含匹配，否则精确匹配；邮箱始终精确匹配且不区分大小写
+	Fuzzy    bool
+	Page     int // 从 1 开始
+	PageSize int
+}
+
+// UserPage 一页查询结果
+type UserPage struct {
+	Users    []UserInfo `json:"users"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+}
+
+// UserRepository 基于 SQLite 的用户信息存储
+type UserRepository struct {
+	db *sql.DB
+}
+
+// OpenUserRepository 打开数据库，执行尚未执行的表结构变更，空库时写入示例数据
+func OpenUserRepository(ctx context.Context, path string) (*UserRepository, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("打开用户数据库失败: %w", err)
+	}
+	r := &UserRepository{db: db}
+	if err := r.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := r.seed(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close 关闭数据库
+func (r *UserRepository) Close() error {
+	return r.db.Close()
+}
+
+// migrate 依次执行未执行过的表结构变更，每个版本在一个事务中完成
+func (r *UserRepository) migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var current int
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("读取迁移版本失败: %w", err)
+	}
+	if current > len(userMigrations) {
+		return fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d", current, len(userMigrations))
+	}
+
+	for version := current + 1; version <= len(userMigrations); version++ {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("执行迁移 %d 失败: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, userMigrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移 %d 失败: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("记录迁移 %d 失败: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("执行迁移 %d 失败: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// seed 用户表为空时写入示例数据
+func (r *UserRepository) seed(ctx context.Context) error {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return fmt.Errorf("统计用户数量失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("写入示例数据失败: %w", err)
+	}
+	defer tx.Rollback()
+	for _, u := range seedUsers {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO users (name, company, title, email) VALUES (?, ?, ?, ?)`,
+			u.Name, u.Company, u.Title, u.Email); err != nil {
+			return fmt.Errorf("写入示例数据失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("写入示例数据失败: %w", err)
+	}
+	return nil
+}
+
+// Search 按条件分页查询，结果按 id 排序
+func (r *UserRepository) Search(ctx context.Context, q UserQuery) (*UserPage, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	match := func(column, value string) {
+		if value == "" {
+			return
+		}
+		if q.Fuzzy {
+			conds = append(conds, column+` LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(value)+"%")
+			return
+		}
+		conds = append(conds, column+" = ?")
+		args = append(args, value)
+	}
+	match("name", strings.TrimSpace(q.Name))
+	match("company", strings.TrimSpace(q.Company))
+	if email := strings.TrimSpace(q.Email); email != "" {
+		conds = append(conds, "email = ?")
+		args = append(args, email)
+	}
+	if len(conds) == 0 {
+		return nil, fmt.Errorf("至少需要姓名、邮箱或公司中的一个查询条件")
+	}
+
+	page, size := q.Page, q.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	where := " WHERE " + strings.Join(conds, " AND ")
+	result := &UserPage{Users: []UserInfo{}, Page: page, PageSize: size}
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT name, company, title, email FROM users`+where+` ORDER BY id LIMIT ? OFFSET ?`,
+		append(args, size, (page-1)*size)...)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u UserInfo
+		if err := rows.Scan(&u.Name, &u.Company, &u.Title, &u.Email); err != nil {
+			return nil, fmt.Errorf("读取用户失败: %w", err)
+		}
+		result.Users = append(result.Users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取用户失败: %w", err)
+	}
+	return result, nil
+}
+
+// escapeLike 转义 LIKE 中的通配符，使用户输入按字面匹配
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
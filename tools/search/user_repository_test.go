@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserRepositorySearch(t *testing.T) {
+	repo := openTestRepository(t)
+	tests := []struct {
+		name      string
+		query     UserQuery
+		wantTotal int
+		wantNames []string
+	}{
+		{name: "精确姓名", query: UserQuery{Name: "王五"}, wantTotal: 1, wantNames: []string{"王五"}},
+		{name: "精确姓名不做包含匹配", query: UserQuery{Name: "王"}, wantTotal: 0},
+		{name: "模糊姓名", query: UserQuery{Name: "王", Fuzzy: true}, wantTotal: 2, wantNames: []string{"王五", "王小明"}},
+		{name: "邮箱不区分大小写", query: UserQuery{Email: "LiSi@Example.com"}, wantTotal: 1, wantNames: []string{"李四"}},
+		{name: "公司", query: UserQuery{Company: "华为"}, wantTotal: 2, wantNames: []string{"王五", "孙丽"}},
+		{name: "模糊公司", query: UserQuery{Company: "字节", Fuzzy: true}, wantTotal: 2, wantNames: []string{"李四", "钱多多"}},
+		{name: "组合条件", query: UserQuery{Name: "张", Company: "阿里巴巴", Fuzzy: true}, wantTotal: 2, wantNames: []string{"张三", "张伟"}},
+		{name: "通配符按字面匹配", query: UserQuery{Name: "%", Fuzzy: true}, wantTotal: 0},
+		{name: "分页", query: UserQuery{Company: "阿里巴巴", Page: 2, PageSize: 2}, wantTotal: 3, wantNames: []string{"吴磊"}},
+		{name: "超出页数", query: UserQuery{Company: "阿里巴巴", Page: 3, PageSize: 2}, wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if page.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", page.Total, tt.wantTotal)
+			}
+			var names []string
+			for _, u := range page.Users {
+				names = append(names, u.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("names = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("names = %v, want %v", names, tt.wantNames)
+				}
+			}
+		})
+	}
+
+	if _, err := repo.Search(context.Background(), UserQuery{Name: " "}); err == nil {
+		t.Errorf("Search() without conditions error = nil")
+	}
+	page, _ := repo.Search(context.Background(), UserQuery{Company: "阿里巴巴", PageSize: 1000})
+	if page.PageSize != maxPageSize || page.Page != 1 {
+		t.Errorf("page = %d, page size = %d", page.Page, page.PageSize)
+	}
+}
+
+func TestOpenUserRepositoryReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users.db")
+	repo, err := OpenUserRepository(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenUserRepository() error = %v", err)
+	}
+	if _, err := repo.db.ExecContext(ctx, `INSERT INTO users (name, email) VALUES ('新用户', 'new@example.com')`); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+	repo.Close()
+
+	// 再次打开时不重复迁移，也不重复写入示例数据
+	repo, err = OpenUserRepository(ctx, path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer repo.Close()
+
+	var users, versions int
+	repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&users)
+	repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&versions)
+	if users != len(seedUsers)+1 || versions != len(userMigrations) {
+		t.Errorf("users = %d, migrations = %d", users, versions)
+	}
+}
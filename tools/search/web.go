@@ -0,0 +1,22 @@
+package search
+
+import (
+	"context"
+
+	duckduckgo "github.com/cloudwego/eino-ext/components/tool/duckduckgo/v2"
+	"github.com/cloudwego/eino/components/tool"
+
+	"common/errs"
+)
+
+// NewWebSearchTool 创建 DuckDuckGo 网页搜索工具，maxResults 为每次返回的结果数
+func NewWebSearchTool(ctx context.Context, maxResults int) (tool.InvokableTool, error) {
+	textSearchTool, err := duckduckgo.NewTextSearchTool(ctx, &duckduckgo.Config{
+		MaxResults: maxResults,
+		Region:     duckduckgo.RegionUS,
+	})
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrToolCall, "创建 duckduckgo 搜索工具", err)
+	}
+	return textSearchTool, nil
+}